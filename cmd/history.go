@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	"github.com/kdruelle/gmd/history"
+	"github.com/spf13/cobra"
+)
+
+var (
+	historyJSON      bool
+	historyContainer string
+	historyLimit     int
+)
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+
+	historyCmd.Flags().BoolVar(&historyJSON, "json", false, "Print the entries as a JSON array, with their steps")
+	historyCmd.Flags().StringVar(&historyContainer, "container", "", "Only print the updates of the container with this name")
+	historyCmd.Flags().IntVarP(&historyLimit, "limit", "n", 0, "Only print the last n updates (0 for all)")
+}
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Print the history of the container updates",
+	Long: `History prints the updates run by gmd and gmd watch, oldest first, from
+` + history.DefaultPath() + `.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		entries, err := history.Load(history.DefaultPath())
+		if err != nil {
+			return err
+		}
+
+		if historyContainer != "" {
+			entries = slices.DeleteFunc(entries, func(e history.Entry) bool {
+				return e.Container != historyContainer
+			})
+		}
+		if historyLimit > 0 && len(entries) > historyLimit {
+			entries = entries[len(entries)-historyLimit:]
+		}
+
+		if historyJSON {
+			if entries == nil {
+				entries = []history.Entry{}
+			}
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(entries)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "STARTED\tDURATION\tHOST\tCONTAINER\tRESULT\tIMAGE\tOLD\tNEW\tOPERATOR")
+		for _, e := range entries {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				e.Started.Local().Format(time.DateTime),
+				e.Ended.Sub(e.Started).Round(time.Second),
+				e.Host,
+				e.Container,
+				e.Result,
+				e.Image(),
+				e.Old.Short(),
+				e.New.Short(),
+				e.Operator,
+			)
+		}
+		return w.Flush()
+	},
+}
@@ -7,7 +7,11 @@ import (
 	_ "embed"
 	"os"
 
+	"github.com/kdruelle/gmd/config"
+	"github.com/kdruelle/gmd/docker/client"
+	"github.com/kdruelle/gmd/history"
 	"github.com/kdruelle/gmd/tui"
+	"github.com/kdruelle/gmd/tui/controllers/containerupdate"
 	"github.com/spf13/cobra"
 )
 
@@ -15,18 +19,42 @@ var version = ""
 var buildDate = ""
 
 var (
-	debugfile string
-	rootCmd   = &cobra.Command{
+	debugfile     string
+	configPath    string
+	clientOptions client.Options
+	updateOptions containerupdate.Options
+	rootCmd       = &cobra.Command{
 		Use:     "gmd",
 		Short:   "TUI to manage docker objects",
 		Long:    `The Definitive TUI to manage docker objects with ease.`,
 		Version: version + " (" + buildDate + ")",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return tui.Start(debugfile)
+			if _, err := loadConfig(cmd); err != nil {
+				return err
+			}
+			return tui.Start(debugfile, clientOptions, updateOptions)
 		},
 	}
 )
 
+// loadConfig reads the configuration file and applies it to the options not
+// set on the command line.
+func loadConfig(cmd *cobra.Command) (config.Config, error) {
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return cfg, err
+	}
+	if !cmd.Flags().Changed("parallel") && cfg.Update.Parallel > 0 {
+		updateOptions.Parallel = cfg.Update.Parallel
+	}
+	updateOptions.Hooks = cfg.Update.Hooks
+	updateOptions.HostHookLabels = cfg.Update.HostHookLabels
+	updateOptions.ComposeHosts = cfg.Update.ComposeHosts
+	updateOptions.History = history.DefaultPath()
+	updateOptions.Operator = history.Operator("")
+	return cfg, nil
+}
+
 func Execute() {
 	err := rootCmd.Execute()
 	if err != nil {
@@ -36,4 +64,18 @@ func Execute() {
 
 func init() {
 	rootCmd.PersistentFlags().StringVarP(&debugfile, "debug", "d", "", "Create a debug file at the chosen location")
+	rootCmd.PersistentFlags().StringVar(&configPath, "config", "", "Path of the configuration file (default $XDG_CONFIG_HOME/gmd/config.yaml)")
+	rootCmd.PersistentFlags().StringArrayVarP(&clientOptions.Hosts, "host", "H", nil, "Daemon socket to connect to (unix://, tcp:// or ssh://), can be repeated")
+	rootCmd.PersistentFlags().StringArrayVarP(&clientOptions.Contexts, "context", "c", nil, "Name of the docker context to use, can be repeated")
+	rootCmd.PersistentFlags().BoolVar(&clientOptions.TLSVerify, "tlsverify", false, "Use TLS and verify the remote daemon")
+	rootCmd.PersistentFlags().StringVar(&clientOptions.TLSCACert, "tlscacert", "", "Trust certs signed only by this CA")
+	rootCmd.PersistentFlags().StringVar(&clientOptions.TLSCert, "tlscert", "", "Path to TLS certificate file")
+	rootCmd.PersistentFlags().StringVar(&clientOptions.TLSKey, "tlskey", "", "Path to TLS key file")
+	rootCmd.PersistentFlags().DurationVar(&clientOptions.Timeouts.Default, "timeout", client.DefaultTimeouts.Default, "Timeout of daemon calls (0 to disable)")
+	rootCmd.PersistentFlags().DurationVar(&clientOptions.Timeouts.Stop, "stop-timeout", client.DefaultTimeouts.Stop, "Timeout of container stops and restarts (0 to disable)")
+	rootCmd.PersistentFlags().DurationVar(&clientOptions.Timeouts.Pull, "pull-timeout", client.DefaultTimeouts.Pull, "Timeout of image pulls (0 to disable)")
+	rootCmd.PersistentFlags().DurationVar(&clientOptions.Timeouts.Registry, "registry-timeout", client.DefaultTimeouts.Registry, "Timeout of registry lookups (0 to disable)")
+	rootCmd.PersistentFlags().DurationVar(&clientOptions.Timeouts.Health, "health-timeout", client.DefaultTimeouts.Health, "Time an updated container has to become healthy (0 to skip the check)")
+	rootCmd.PersistentFlags().DurationVar(&clientOptions.Timeouts.Stable, "health-stable", client.DefaultTimeouts.Stable, "Time an updated container without health check must keep running (0 to only wait for it to run)")
+	rootCmd.PersistentFlags().IntVar(&updateOptions.Parallel, "parallel", containerupdate.DefaultOptions.Parallel, "Number of containers a batch update updates at once")
 }
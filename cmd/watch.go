@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/kdruelle/gmd/docker/cache"
+	"github.com/kdruelle/gmd/docker/client"
+	"github.com/kdruelle/gmd/history"
+	"github.com/kdruelle/gmd/notify"
+	"github.com/kdruelle/gmd/watch"
+	"github.com/spf13/cobra"
+)
+
+var (
+	watchOptions   watch.Options
+	watchLogFormat string
+	watchLogLevel  string
+)
+
+func init() {
+	rootCmd.AddCommand(watchCmd)
+
+	watchCmd.Flags().StringVar(&watchOptions.Schedule, "schedule", "", "Cron expression of the update checks, e.g. \"0 4 * * *\" or \"@every 6h\" (default \""+watch.DefaultSchedule+"\")")
+	watchCmd.Flags().BoolVar(&watchOptions.Now, "now", false, "Check for updates at start-up, before the first scheduled check")
+	watchCmd.Flags().StringVar(&watchLogFormat, "log-format", "text", "Log format: text or json")
+	watchCmd.Flags().StringVar(&watchLogLevel, "log-level", "info", "Log level: debug, info, warn or error")
+}
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Check for updates on a schedule and update containers with the auto policy, without the TUI",
+	Long: `Watch runs headless. On a cron schedule, it checks every container for an
+update, logs the available ones, and updates the containers labeled
+gmd.update.policy=auto with the same pipeline as the TUI: hooks, health check
+and rollback on failure.
+
+SIGINT and SIGTERM stop the watch once the running updates ended.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig(cmd)
+		if err != nil {
+			return err
+		}
+		if watchOptions.Schedule == "" {
+			watchOptions.Schedule = cfg.Watch.Schedule
+		}
+		watchOptions.Update = updateOptions
+		watchOptions.Update.Operator = history.Operator("watch")
+
+		notifier, err := notify.New(cfg.Notify)
+		if err != nil {
+			return err
+		}
+		if notifier.Len() > 0 {
+			// the updates notified are kept next to the history.
+			if updateOptions.History != "" {
+				if err := notifier.Restore(filepath.Join(filepath.Dir(updateOptions.History), "notified.json")); err != nil {
+					return err
+				}
+			}
+			watchOptions.Notifier = notifier
+		}
+
+		logger, err := newLogger(os.Stderr, watchLogFormat, watchLogLevel)
+		if err != nil {
+			return err
+		}
+
+		// the docker client logs for debugging only, see --debug.
+		if debugfile != "" {
+			f, err := os.OpenFile(debugfile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			log.SetOutput(f)
+		} else {
+			log.SetOutput(io.Discard)
+		}
+
+		clis, err := client.NewClients(clientOptions)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			for _, cli := range clis {
+				cli.Close()
+			}
+		}()
+		group, err := cache.NewGroup(clis...)
+		if err != nil {
+			return err
+		}
+		w, err := watch.New(group, watchOptions, logger)
+		if err != nil {
+			return err
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		return w.Run(ctx)
+	},
+}
+
+// newLogger returns a structured logger writing to w in the given format
+// and from the given level.
+func newLogger(w io.Writer, format, level string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q", level)
+	}
+	opts := &slog.HandlerOptions{Level: lvl}
+
+	switch format {
+	case "text":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	default:
+		return nil, fmt.Errorf("invalid log format %q", format)
+	}
+}
@@ -0,0 +1,154 @@
+// Package config reads the configuration file of gmd, a YAML file found at
+// $XDG_CONFIG_HOME/gmd/config.yaml by default.
+//
+//	update:
+//	  parallel: 4
+//	  hooks:
+//	    postgres:
+//	      pre-stop:
+//	        exec: pg_dumpall -U postgres > /backup/dump.sql
+//	    app:
+//	      post-start:
+//	        host: curl -fsS http://localhost:8080/warmup
+//	  host-hook-labels: [app]
+//	  compose-hosts: [prod]
+//	watch:
+//	  schedule: "0 4 * * *"
+//	notify:
+//	  ntfy:
+//	    - url: https://ntfy.sh/my-gmd-topic
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the configuration of gmd.
+type Config struct {
+	Update Update `yaml:"update"` // Update configures container updates.
+	Watch  Watch  `yaml:"watch"`  // Watch configures gmd watch.
+	Notify Notify `yaml:"notify"` // Notify configures the notifications of gmd watch.
+}
+
+// Update configures container updates.
+type Update struct {
+	Parallel int              `yaml:"parallel"` // Parallel is the number of containers a batch updates at once, 0 for the default.
+	Hooks    map[string]Hooks `yaml:"hooks"`    // Hooks are the hooks of the containers, by container name.
+
+	// HostHookLabels are the containers whose gmd.hooks.<stage>.host labels
+	// are run on the host running gmd, by name or host/name. Host hooks of
+	// other containers only come from Hooks: labels come from images and from
+	// whoever can create containers on the daemon.
+	HostHookLabels []string `yaml:"host-hook-labels"`
+
+	// ComposeHosts are the remote hosts, by name, whose compose containers
+	// are recreated with docker compose, from the compose files of this
+	// machine. Only the containers of local daemons are by default.
+	ComposeHosts []string `yaml:"compose-hosts"`
+}
+
+// Watch configures gmd watch.
+type Watch struct {
+	Schedule string `yaml:"schedule"` // Schedule is the cron expression of the update checks, empty for the default.
+}
+
+// Notify configures the backends notified when an update is available, or
+// when an update or its rollback failed. Every backend is notified.
+type Notify struct {
+	Webhooks []Webhook `yaml:"webhooks"` // Webhooks receive the notifications as JSON.
+	SMTP     []SMTP    `yaml:"smtp"`     // SMTP sends the notifications by mail.
+	Ntfy     []Ntfy    `yaml:"ntfy"`     // Ntfy publishes the notifications to ntfy topics.
+	Gotify   []Gotify  `yaml:"gotify"`   // Gotify pushes the notifications to Gotify servers.
+}
+
+// Webhook is an URL the notifications are posted to as JSON.
+type Webhook struct {
+	URL     string            `yaml:"url"`     // URL is the URL of the webhook.
+	Headers map[string]string `yaml:"headers"` // Headers are added to the requests, e.g. Authorization.
+}
+
+// SMTP is a mail server the notifications are sent through.
+type SMTP struct {
+	Host     string   `yaml:"host"`     // Host is the host name of the server.
+	Port     int      `yaml:"port"`     // Port is the port of the server, 587 if unset.
+	TLS      bool     `yaml:"tls"`      // TLS connects with TLS, as on port 465, instead of upgrading with STARTTLS.
+	Username string   `yaml:"username"` // Username authenticates to the server if set.
+	Password string   `yaml:"password"` // Password is the password of Username.
+	From     string   `yaml:"from"`     // From is the sender address.
+	To       []string `yaml:"to"`       // To are the recipient addresses.
+}
+
+// Ntfy is a ntfy topic the notifications are published to.
+type Ntfy struct {
+	URL      string `yaml:"url"`      // URL is the URL of the topic, e.g. https://ntfy.sh/my-topic.
+	Token    string `yaml:"token"`    // Token is the access token of the topic, if it is protected.
+	Priority int    `yaml:"priority"` // Priority is the priority of the messages (1 to 5), the default of the server if unset.
+}
+
+// Gotify is a Gotify server the notifications are pushed to.
+type Gotify struct {
+	URL      string `yaml:"url"`      // URL is the URL of the server.
+	Token    string `yaml:"token"`    // Token is the token of the application the messages are sent as.
+	Priority int    `yaml:"priority"` // Priority is the priority of the messages, 0 if unset.
+}
+
+// Hooks are the hooks of a container, by stage of the update
+// (pre-pull, pre-stop, post-start, on-failure).
+type Hooks map[string]Hook
+
+// Hook is a command run at a stage of the update of a container.
+// Both commands are run by sh -c, the one inside the container first.
+type Hook struct {
+	Exec string `yaml:"exec"` // Exec is the command run inside the container.
+	Host string `yaml:"host"` // Host is the command run on the host running gmd.
+}
+
+// DefaultPath returns the path of the configuration file read when none is
+// given: gmd/config.yaml under $XDG_CONFIG_HOME, or under ~/.config if it is
+// not set.
+func DefaultPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "gmd", "config.yaml")
+}
+
+// Load reads the configuration file at the given path, or at DefaultPath if
+// path is empty. A missing default file is an empty configuration, a missing
+// given file is an error.
+// It returns an error if the file could not be read or parsed.
+func Load(path string) (Config, error) {
+	var cfg Config
+
+	explicit := path != ""
+	if !explicit {
+		path = DefaultPath()
+		if path == "" {
+			return cfg, nil
+		}
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if !explicit && errors.Is(err, fs.ErrNotExist) {
+			return cfg, nil
+		}
+		return cfg, err
+	}
+
+	if err := yaml.Unmarshal(raw, &cfg); err != nil {
+		return cfg, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
@@ -1,66 +1,149 @@
-// Package cache provides a cache of Docker containers and images.
+// Package cache provides a cache of Docker containers, images, volumes and networks.
 // It also provides a mechanism to receive events from Docker and
 // update the cache accordingly.
 package cache
 
 import (
+	"context"
 	"sync"
+	"sync/atomic"
 
+	"github.com/alitto/pond/v2"
 	"github.com/docker/docker/api/types/events"
 	"github.com/kdruelle/gmd/docker/client"
 	"github.com/kdruelle/gmd/docker/types"
 )
 
-// Cache represents a cache of Docker containers and images.
+// Cache represents a cache of Docker containers, images, volumes and networks.
 type Cache struct {
-	cli               *client.Client              // cli is the Docker client used to interact with the Docker daemon.
-	mu                sync.RWMutex                // mu is a read-write mutex used to protect access to the cache.
-	images            map[string]*types.Image     // images is a map of image IDs to their corresponding corresponding Image objects.
-	containers        map[string]*types.Container // containers is a map of container IDs to their respective Container objects.
-	ievents           <-chan events.Message       // ieEvents is a channel of events received from Docker.
-	ierrors           <-chan error                // ierrors is a channel of errors received from Docker.
-	events            chan Event                  // events is a channel of events generated by the cache, such as when the cache is updated or when a container is deleted.
-	containerDeletion chan string                 // containerDeletion is a channel of container IDs that are being deleted.
+	cli               *client.Client                // cli is the Docker client used to interact with the Docker daemon.
+	host              string                        // host is the name of the daemon endpoint, stored in every cached object.
+	mu                sync.RWMutex                  // mu is a read-write mutex used to protect access to the cache.
+	images            map[string]*types.Image       // images is a map of image IDs to their corresponding corresponding Image objects.
+	containers        map[string]*types.Container   // containers is a map of container IDs to their respective Container objects.
+	volumes           map[string]*types.Volume      // volumes is a map of volume names to their respective Volume objects.
+	networks          map[string]*types.Network     // networks is a map of network IDs to their respective Network objects.
+	ievents           <-chan events.Message         // ieEvents is a channel of events received from Docker.
+	ierrors           <-chan error                  // ierrors is a channel of errors received from Docker.
+	events            chan Event                    // events is a channel of events generated by the cache, such as when the cache is updated or when a container is deleted.
+	containerDeletion chan string                   // containerDeletion is a channel of container IDs that are being deleted.
+	ctx               context.Context               // ctx is the context of every call made by the cache, canceled when the cache is closed.
+	cancel            context.CancelFunc            // cancel cancels ctx.
+	pool              pond.Pool                     // pool bounds the number of concurrent calls made to load the cache.
+	progress          progress                      // progress counts the objects loaded so far.
+	subsMu            sync.Mutex                    // subsMu protects subs.
+	subs              map[<-chan Change]*subscriber // subs are the subscribers of the cache, by channel.
+}
+
+// snapshotWorkers is the maximum number of concurrent calls made to the
+// daemon while loading a cache.
+const snapshotWorkers = 16
+
+// Progress reports how far the loading of the caches went.
+type Progress struct {
+	Containers      int // Containers is the number of containers inspected so far.
+	ContainersTotal int // ContainersTotal is the number of containers to inspect.
+	Images          int // Images is the number of images whose history was read so far.
+	ImagesTotal     int // ImagesTotal is the number of images whose history is to be read.
+}
+
+// progress holds the counters of Progress, updated by the loading workers.
+type progress struct {
+	containers      atomic.Int64
+	containersTotal atomic.Int64
+	images          atomic.Int64
+	imagesTotal     atomic.Int64
 }
 
 // NewCache returns a new Cache object.
 func NewCache(cli *client.Client) *Cache {
 	c := &Cache{
 		cli:               cli,
+		host:              cli.Endpoint().Name,
 		images:            make(map[string]*types.Image),
 		containers:        make(map[string]*types.Container),
+		volumes:           make(map[string]*types.Volume),
+		networks:          make(map[string]*types.Network),
 		events:            make(chan Event, 20),
 		containerDeletion: make(chan string, 20),
+		subs:              make(map[<-chan Change]*subscriber),
 	}
+	c.ctx, c.cancel = context.WithCancel(context.Background())
+	c.pool = pond.NewPool(snapshotWorkers, pond.WithContext(c.ctx))
 
 	return c
 }
 
 // LoadAndStart loads the cache with the current state of the Docker daemon
 // and starts listening for events.
+// Containers are loaded first, then images, volumes and networks. The intermediate images found in
+// the images history are added in the background, and an
+// ImagesHistoryLoadedEventType event is emitted once they are all added.
+// Objects that vanish while the cache is loading are left out. If the
+// daemon could not be listed, the cache stays empty and an error is returned.
 func (c *Cache) LoadAndStart() error {
-	c.ievents, c.ierrors = c.cli.StartEvents()
+	// the engine is detected before subscribing to its events.
+	if err := c.cli.Ping(c.ctx); err != nil {
+		c.loadFailed()
+		return err
+	}
+	c.ievents, c.ierrors = c.cli.StartEvents(c.ctx)
+
+	conts, err := c.snapshotContainers()
+	if err != nil {
+		c.loadFailed()
+		return err
+	}
+	c.apply(func(out *[]Change) {
+		for _, cont := range conts {
+			c.putContainer(cont, out)
+		}
+	})
+
+	c.emit(Event{EventType: ContainersLoadedEventType, Host: c.host})
 
-	imgs := c.snapshotImages()
-	c.mu.Lock()
-	for _, img := range imgs {
-		c.images[img.ID] = img
+	imgs, err := c.snapshotImages()
+	if err != nil {
+		c.loadFailed()
+		return err
 	}
-	c.mu.Unlock()
+	c.apply(func(out *[]Change) {
+		for _, img := range imgs {
+			c.putImage(img, out)
+		}
+	})
 
-	c.events <- Event{EventType: ImagesLoadedEventType}
+	c.emit(Event{EventType: ImagesLoadedEventType, Host: c.host})
 
-	conts := c.snapshotContainers()
-	c.mu.Lock()
-	for _, cont := range conts {
-		c.containers[cont.ID] = cont
+	vols, err := c.snapshotVolumes()
+	if err != nil {
+		c.loadFailed()
+		return err
 	}
-	c.mu.Unlock()
+	c.apply(func(out *[]Change) {
+		for _, v := range vols {
+			c.putVolume(v, out)
+		}
+	})
 
-	c.events <- Event{EventType: ContainersLoadedEventType}
+	c.emit(Event{EventType: VolumesLoadedEventType, Host: c.host})
+
+	nets, err := c.snapshotNetworks()
+	if err != nil {
+		c.loadFailed()
+		return err
+	}
+	c.apply(func(out *[]Change) {
+		for _, n := range nets {
+			c.putNetwork(n, out)
+		}
+	})
+
+	c.emit(Event{EventType: NetworksLoadedEventType, Host: c.host})
 
 	go c.listenEvents()
 	go c.containerDeleteWorker()
+	go c.loadHistory(imgs)
 
 	// for i := range m.containers {
 	// 	go m.checkUpdate(m.containers[i])
@@ -69,3 +152,54 @@ func (c *Cache) LoadAndStart() error {
 
 	return nil
 }
+
+// loadFailed stops listening for events and tells the consumer that the
+// cache will not emit the remaining loaded events.
+func (c *Cache) loadFailed() {
+	c.cli.StopEvents()
+	c.emit(Event{EventType: loadFailedEventType, Host: c.host})
+}
+
+// Progress returns how far the loading of the cache went.
+func (c *Cache) Progress() Progress {
+	return Progress{
+		Containers:      int(c.progress.containers.Load()),
+		ContainersTotal: int(c.progress.containersTotal.Load()),
+		Images:          int(c.progress.images.Load()),
+		ImagesTotal:     int(c.progress.imagesTotal.Load()),
+	}
+}
+
+// Host returns the name of the daemon endpoint of the cache.
+func (c *Cache) Host() string {
+	return c.host
+}
+
+// Client returns the Docker client used by the cache.
+func (c *Cache) Client() *client.Client {
+	return c.cli
+}
+
+// Close stops listening for events, cancels the pending calls to the daemon
+// and releases the cache workers.
+// The channel returned by Done is closed, so consumers waiting on Events
+// can stop waiting, and the channels of every subscription are closed.
+// Close can be called more than once.
+func (c *Cache) Close() {
+	c.cancel()
+	c.cli.StopEvents()
+	c.closeSubscriptions()
+}
+
+// Done returns a channel that is closed when the cache is closed.
+func (c *Cache) Done() <-chan struct{} {
+	return c.ctx.Done()
+}
+
+// emit sends an event to the cache consumer, unless the cache is closed.
+func (c *Cache) emit(ev Event) {
+	select {
+	case c.events <- ev:
+	case <-c.ctx.Done():
+	}
+}
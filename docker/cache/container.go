@@ -2,6 +2,7 @@ package cache
 
 import (
 	"log"
+	"slices"
 	"time"
 
 	"github.com/docker/docker/api/types/events"
@@ -43,64 +44,87 @@ func (c *Cache) Container(id string) (types.Container, error) {
 // The function does not return an error.
 func (c *Cache) refreshContainer(ev events.Message) {
 
-	cont, err := c.cli.ContainerInspect(ev.Actor.ID)
+	cont, err := c.cli.ContainerInspect(c.ctx, ev.Actor.ID)
 
-	c.mu.Lock()
-
-	if err == nil {
-
-		summary := &types.Container{
-			InspectResponse: cont,
-		}
-		c.containers[ev.Actor.ID] = summary
-
-		if ev.Action == events.ActionDestroy {
-			c.mu.Unlock()
-			log.Printf("try to wait for container %s deletion", ev.Actor.ID)
-			c.containerDeletion <- ev.Actor.ID
+	c.apply(func(out *[]Change) {
+		if err != nil {
+			log.Printf("refresh container %s, delete container: %v", ev.Actor.ID, err)
+			c.removeContainer(ev.Actor.ID, out)
 			return
 		}
-
-	} else {
-		log.Printf("refresh container %s, delete container: %v", ev.Actor.ID, err)
-		delete(c.containers, ev.Actor.ID)
+		c.putContainer(&types.Container{
+			InspectResponse: cont,
+			Host:            c.host,
+		}, out)
+	})
+
+	if err == nil && ev.Action == events.ActionDestroy {
+		log.Printf("try to wait for container %s deletion", ev.Actor.ID)
+		select {
+		case c.containerDeletion <- ev.Actor.ID:
+		case <-c.ctx.Done():
+		}
 	}
-	c.mu.Unlock()
 }
 
-func (c *Cache) snapshotContainers() []*types.Container {
-	ctnrs, err := c.cli.ContainerList()
+// snapshotContainers lists and inspects every container of the daemon.
+// The containers are inspected in parallel through the cache pool.
+// It returns an error if the containers could not be listed. A container
+// that could not be inspected, e.g. because it was removed since it was
+// listed, is left out of the snapshot.
+func (c *Cache) snapshotContainers() ([]*types.Container, error) {
+	ctnrs, err := c.cli.ContainerList(c.ctx)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
 	containers := make([]*types.Container, len(ctnrs))
+	c.progress.containers.Store(0)
+	c.progress.containersTotal.Store(int64(len(ctnrs)))
 
+	group := c.pool.NewGroup()
 	for i, container := range ctnrs {
-		inspect, err := c.cli.ContainerInspect(container.ID)
-		if err != nil {
-			panic(err)
-		}
-		containers[i] = &types.Container{
-			InspectResponse: inspect,
-		}
+		group.Submit(func() {
+			defer c.progress.containers.Add(1)
+			inspect, err := c.cli.ContainerInspect(c.ctx, container.ID)
+			if err != nil {
+				log.Printf("snapshot container %s, skipped: %v", container.ID, err)
+				return
+			}
+			containers[i] = &types.Container{
+				InspectResponse: inspect,
+				Host:            c.host,
+			}
+		})
+	}
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+	if err := c.ctx.Err(); err != nil {
+		return nil, err
 	}
 
-	return containers
+	return slices.DeleteFunc(containers, func(cont *types.Container) bool { return cont == nil }), nil
 }
 
 func (c *Cache) containerDeleteWorker() {
-	for id := range c.containerDeletion {
+	for {
+		var id string
+		select {
+		case id = <-c.containerDeletion:
+		case <-c.ctx.Done():
+			return
+		}
 
 		for range 25 { // max 5 secondes
-			cont, err := c.cli.ContainerInspect(id)
+			cont, err := c.cli.ContainerInspect(c.ctx, id)
 			if err != nil {
 				log.Printf("delete container %s: %v", id, err)
-				c.mu.Lock()
-				delete(c.containers, id)
-				c.mu.Unlock()
+				c.apply(func(out *[]Change) {
+					c.removeContainer(id, out)
+				})
 
-				c.events <- Event{EventType: ContainerEventType, ActorID: id}
+				c.emit(Event{EventType: ContainerEventType, ActorID: id, Host: c.host})
 				break
 			}
 			log.Printf("deleted container %s is still there: %+v", id, cont)
@@ -3,6 +3,7 @@ package cache
 import (
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/docker/docker/api/types/events"
 )
@@ -10,15 +11,33 @@ import (
 type EventType string
 
 const (
-	ImagesLoadedEventType     EventType = "images-loaded"
-	ImageEventType            EventType = EventType(events.ImageEventType)
-	ContainersLoadedEventType EventType = "containers-loaded"
-	ContainerEventType        EventType = EventType(events.ContainerEventType)
+	ImagesLoadedEventType        EventType = "images-loaded"
+	ImageEventType               EventType = EventType(events.ImageEventType)
+	ContainersLoadedEventType    EventType = "containers-loaded"
+	ContainerEventType           EventType = EventType(events.ContainerEventType)
+	ImagesHistoryLoadedEventType EventType = "images-history-loaded"
+	VolumesLoadedEventType       EventType = "volumes-loaded"
+	VolumeEventType              EventType = EventType(events.VolumeEventType)
+	NetworksLoadedEventType      EventType = "networks-loaded"
+	NetworkEventType             EventType = EventType(events.NetworkEventType)
+	DisconnectedEventType        EventType = "disconnected"
+	ReconnectedEventType         EventType = "reconnected"
+
+	// loadFailedEventType is emitted by a cache that could not be loaded.
+	// It is only used by Group and never forwarded.
+	loadFailedEventType EventType = "load-failed"
+)
+
+const (
+	reconnectMinDelay = 1 * time.Second        // reconnectMinDelay is the delay before the first reconnection attempt.
+	reconnectMaxDelay = 30 * time.Second       // reconnectMaxDelay caps the delay between reconnection attempts.
+	imageEventsWindow = 200 * time.Millisecond // imageEventsWindow is the delay during which image events are coalesced.
 )
 
 type Event struct {
 	EventType EventType
 	ActorID   string
+	Host      string // Host is the name of the daemon endpoint the event comes from.
 }
 
 // Events returns a channel of Event objects.
@@ -28,7 +47,24 @@ func (c *Cache) Events() <-chan Event {
 	return c.events
 }
 
+// listenEvents applies the daemon events to the cache until the cache is closed.
+// Image events are coalesced: a pull or a prune sends a burst of them, they
+// are applied together once no event came for imageEventsWindow, keeping the
+// last action of every image.
+// When the event stream fails, e.g. when the daemon restarts, a
+// DisconnectedEventType event is emitted and the cache reconnects.
 func (c *Cache) listenEvents() {
+	var (
+		images = make(map[string]events.Message) // images is the last pending event of every image.
+		flush  *time.Timer
+	)
+	flushC := func() <-chan time.Time {
+		if flush == nil {
+			return nil
+		}
+		return flush.C
+	}
+
 	for {
 		select {
 		case msg, ok := <-c.ievents:
@@ -36,16 +72,73 @@ func (c *Cache) listenEvents() {
 				return
 			}
 			log.Printf("lib docker - received event: %+v", msg)
+			if msg.Type == events.ImageEventType {
+				images[msg.Actor.ID] = msg
+				if flush == nil {
+					flush = time.NewTimer(imageEventsWindow)
+				} else {
+					flush.Reset(imageEventsWindow)
+				}
+				continue
+			}
 			if ev, err := c.handleEvent(msg); err == nil {
-				c.events <- ev
+				c.emit(ev)
 			}
-		case <-c.ierrors:
-			//	m.errsCh <- err
+		case <-flushC():
+			flush = nil
+			changes := c.refreshImages(images)
+			for _, ch := range changes {
+				c.emit(Event{EventType: ImageEventType, ActorID: ch.ID, Host: c.host})
+			}
+			clear(images)
+		case err := <-c.ierrors:
+			if c.ctx.Err() != nil {
+				return
+			}
+			log.Printf("lib docker - event stream of %s failed: %v", c.host, err)
+			c.emit(Event{EventType: DisconnectedEventType, Host: c.host})
+			if flush != nil {
+				flush.Stop()
+				flush = nil
+			}
+			// the resync done by reconnect covers the pending image events.
+			clear(images)
+			if !c.reconnect() {
+				return
+			}
+			c.emit(Event{EventType: ReconnectedEventType, Host: c.host})
+		case <-c.ctx.Done():
 			return
 		}
 	}
 }
 
+// reconnect waits for the daemon to answer again, with an exponential
+// backoff, then subscribes to its events and resynchronizes the cache.
+// It returns false if the cache was closed in the meantime.
+func (c *Cache) reconnect() bool {
+	delay := reconnectMinDelay
+	for {
+		select {
+		case <-time.After(delay):
+		case <-c.ctx.Done():
+			return false
+		}
+
+		err := c.cli.Ping(c.ctx)
+		if err == nil {
+			c.cli.StopEvents()
+			c.ievents, c.ierrors = c.cli.StartEvents(c.ctx)
+			if err = c.resync(); err == nil {
+				return true
+			}
+		}
+		log.Printf("lib docker - reconnection to %s failed: %v", c.host, err)
+
+		delay = min(delay*2, reconnectMaxDelay)
+	}
+}
+
 func (c *Cache) handleEvent(e events.Message) (Event, error) {
 
 	switch e.Type {
@@ -56,15 +149,42 @@ func (c *Cache) handleEvent(e events.Message) (Event, error) {
 		return Event{
 			EventType: ContainerEventType,
 			ActorID:   e.Actor.ID,
+			Host:      c.host,
 		}, nil
 
-	case events.ImageEventType:
-		log.Printf("lib docker - received image event: %+v", e)
-		c.refreshImage(e.Actor.ID)
+	case events.VolumeEventType:
+		log.Printf("lib docker - received volume event: %+v", e)
+		c.refreshVolume(e)
+
+		return Event{
+			EventType: VolumeEventType,
+			ActorID:   e.Actor.ID,
+			Host:      c.host,
+		}, nil
+
+	case events.NetworkEventType:
+		log.Printf("lib docker - received network event: %+v", e)
+		c.refreshNetwork(e)
+
+		// the daemon sends no container event when a container is connected
+		// to or disconnected from a network, the container is refreshed here.
+		if id := e.Actor.Attributes["container"]; id != "" && (e.Action == events.ActionConnect || e.Action == events.ActionDisconnect) {
+			c.refreshContainer(events.Message{
+				Type:   events.ContainerEventType,
+				Action: e.Action,
+				Actor:  events.Actor{ID: id},
+			})
+			c.emit(Event{
+				EventType: ContainerEventType,
+				ActorID:   id,
+				Host:      c.host,
+			})
+		}
 
 		return Event{
-			EventType: ImageEventType,
+			EventType: NetworkEventType,
 			ActorID:   e.Actor.ID,
+			Host:      c.host,
 		}, nil
 	}
 
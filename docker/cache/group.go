@@ -0,0 +1,353 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/kdruelle/gmd/docker/client"
+	"github.com/kdruelle/gmd/docker/types"
+)
+
+// Group merges the caches of several Docker daemons behind a single view.
+//
+// Every object returned by a Group carries the name of the host it comes
+// from, and objects are looked up by host and ID since IDs are only unique
+// per daemon. A Group with a single cache behaves like that cache.
+type Group struct {
+	caches []*Cache          // caches is the list of caches, in the order the hosts were given.
+	byHost map[string]*Cache // byHost is a map of host names to their cache.
+	events chan Event        // events is the merged channel of events of every cache.
+	done   chan struct{}     // done is closed when the group is closed.
+	once   sync.Once         // once guards the closing of done.
+}
+
+// NewGroup returns a new Group with a cache for every given client.
+// It returns an error if two clients have the same host name, since objects
+// are looked up by host; no cache is created then and the clients are left
+// to the caller to close.
+func NewGroup(clis ...*client.Client) (*Group, error) {
+	g := &Group{
+		caches: make([]*Cache, 0, len(clis)),
+		byHost: make(map[string]*Cache, len(clis)),
+		events: make(chan Event, 20),
+		done:   make(chan struct{}),
+	}
+	seen := make(map[string]bool, len(clis))
+	for _, cli := range clis {
+		name := cli.Endpoint().Name
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate host %s", name)
+		}
+		seen[name] = true
+	}
+	for _, cli := range clis {
+		c := NewCache(cli)
+		g.caches = append(g.caches, c)
+		g.byHost[c.Host()] = c
+	}
+	return g, nil
+}
+
+// LoadAndStart loads every cache in parallel and starts forwarding their
+// events. Loaded events are only emitted once every cache is loaded.
+// The returned error joins the errors of every host.
+func (g *Group) LoadAndStart() error {
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		errs   []error
+		loaded = make(map[EventType]int)
+	)
+
+	for _, c := range g.caches {
+		go g.forward(c, &mu, loaded)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := c.LoadAndStart(); err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("%s: %w", c.Host(), err))
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// forward copies the events of the given cache to the group channel.
+// Loaded events are counted and only the last one is forwarded. A cache
+// that fails to load counts as loaded, so the other hosts are still shown.
+func (g *Group) forward(c *Cache, mu *sync.Mutex, loaded map[EventType]int) {
+	seen := make(map[EventType]bool, 4)
+
+	// count returns whether ev is the last loaded event of its type.
+	count := func(ev EventType) bool {
+		seen[ev] = true
+		mu.Lock()
+		defer mu.Unlock()
+		loaded[ev]++
+		return loaded[ev] == len(g.caches)
+	}
+
+	for {
+		select {
+		case ev := <-c.Events():
+			var out []Event
+			switch ev.EventType {
+			case ImagesLoadedEventType, ContainersLoadedEventType, VolumesLoadedEventType, NetworksLoadedEventType:
+				if count(ev.EventType) {
+					out = append(out, ev)
+				}
+			case loadFailedEventType:
+				for _, t := range []EventType{ContainersLoadedEventType, ImagesLoadedEventType, VolumesLoadedEventType, NetworksLoadedEventType} {
+					if !seen[t] && count(t) {
+						out = append(out, Event{EventType: t, Host: ev.Host})
+					}
+				}
+			default:
+				out = append(out, ev)
+			}
+			for _, ev := range out {
+				select {
+				case g.events <- ev:
+				case <-g.done:
+					return
+				}
+			}
+		case <-g.done:
+			return
+		}
+	}
+}
+
+// Events returns the merged channel of events of every cache.
+func (g *Group) Events() <-chan Event {
+	return g.events
+}
+
+// Subscribe returns a channel receiving the changes of every cache selected
+// by the given filter. The changes of a host are received in the order they
+// were applied.
+// The channel is closed by Unsubscribe or when the group is closed.
+func (g *Group) Subscribe(filter Filter) <-chan Change {
+	s := newSubscriber(filter)
+	for _, c := range g.caches {
+		c.subscribe(s)
+	}
+	if len(g.caches) == 0 {
+		s.close()
+	}
+	return s.out
+}
+
+// Unsubscribe ends the subscription of the given channel and closes it.
+func (g *Group) Unsubscribe(ch <-chan Change) {
+	for _, c := range g.caches {
+		c.Unsubscribe(ch)
+	}
+}
+
+// Close closes every cache of the group.
+func (g *Group) Close() {
+	g.once.Do(func() {
+		close(g.done)
+		for _, c := range g.caches {
+			c.Close()
+		}
+	})
+}
+
+// Done returns a channel that is closed when the group is closed.
+func (g *Group) Done() <-chan struct{} {
+	return g.done
+}
+
+// Progress returns how far the loading of every cache went.
+func (g *Group) Progress() Progress {
+	var out Progress
+	for _, c := range g.caches {
+		p := c.Progress()
+		out.Containers += p.Containers
+		out.ContainersTotal += p.ContainersTotal
+		out.Images += p.Images
+		out.ImagesTotal += p.ImagesTotal
+	}
+	return out
+}
+
+// Hosts returns the host names of the group, in the order they were given.
+func (g *Group) Hosts() []string {
+	out := make([]string, len(g.caches))
+	for i, c := range g.caches {
+		out[i] = c.Host()
+	}
+	return out
+}
+
+// Endpoints returns the endpoints of the group, in the order they were given.
+func (g *Group) Endpoints() []client.Endpoint {
+	out := make([]client.Endpoint, len(g.caches))
+	for i, c := range g.caches {
+		out[i] = c.Client().Endpoint()
+	}
+	return out
+}
+
+// Clients returns the clients of the group, in the order they were given.
+func (g *Group) Clients() []*client.Client {
+	out := make([]*client.Client, len(g.caches))
+	for i, c := range g.caches {
+		out[i] = c.Client()
+	}
+	return out
+}
+
+// Timeouts returns the timeouts of the clients of the group.
+func (g *Group) Timeouts() client.Timeouts {
+	if len(g.caches) == 0 {
+		return client.DefaultTimeouts
+	}
+	return g.caches[0].Client().Timeouts()
+}
+
+// MultiHost reports whether the group spans more than one daemon.
+func (g *Group) MultiHost() bool {
+	return len(g.caches) > 1
+}
+
+// Cache returns the cache of the given host, or nil if the host is unknown.
+func (g *Group) Cache(host string) *Cache {
+	return g.byHost[host]
+}
+
+// Client returns the client of the given host, or nil if the host is unknown.
+func (g *Group) Client(host string) *client.Client {
+	if c, ok := g.byHost[host]; ok {
+		return c.Client()
+	}
+	return nil
+}
+
+// Containers returns the containers of every host.
+func (g *Group) Containers() []types.Container {
+	var out []types.Container
+	for _, c := range g.caches {
+		out = append(out, c.Containers()...)
+	}
+	return out
+}
+
+// Container returns the container with the given ID on the given host.
+// If the container is not found, ErrContainerNotFound is returned.
+func (g *Group) Container(host, id string) (types.Container, error) {
+	c, ok := g.byHost[host]
+	if !ok {
+		return types.Container{}, ErrContainerNotFound
+	}
+	return c.Container(id)
+}
+
+// Images returns the images of every host, sorted by tag then host.
+func (g *Group) Images() []types.Image {
+	var out []types.Image
+	for _, c := range g.caches {
+		out = append(out, c.Images()...)
+	}
+	sortImages(out)
+	return out
+}
+
+// Image returns the image with the given ID on the given host.
+// If the image is not found, ErrImageNotFound is returned.
+func (g *Group) Image(host, id string) (types.Image, error) {
+	c, ok := g.byHost[host]
+	if !ok {
+		return types.Image{}, ErrImageNotFound
+	}
+	return c.Image(id)
+}
+
+// ImagesUnused returns the unused images of every host, sorted by tag then host.
+func (g *Group) ImagesUnused() []types.Image {
+	var out []types.Image
+	for _, c := range g.caches {
+		out = append(out, c.ImagesUnused()...)
+	}
+	sortImages(out)
+	return out
+}
+
+// Volumes returns the volumes of every host, sorted by name then host.
+func (g *Group) Volumes() []types.Volume {
+	var out []types.Volume
+	for _, c := range g.caches {
+		out = append(out, c.Volumes()...)
+	}
+	sortVolumes(out)
+	return out
+}
+
+// Volume returns the volume with the given name on the given host.
+// If the volume is not found, ErrVolumeNotFound is returned.
+func (g *Group) Volume(host, name string) (types.Volume, error) {
+	c, ok := g.byHost[host]
+	if !ok {
+		return types.Volume{}, ErrVolumeNotFound
+	}
+	return c.Volume(name)
+}
+
+// VolumesUnused returns the unused volumes of every host, sorted by name then host.
+func (g *Group) VolumesUnused() []types.Volume {
+	var out []types.Volume
+	for _, c := range g.caches {
+		out = append(out, c.VolumesUnused()...)
+	}
+	sortVolumes(out)
+	return out
+}
+
+// Networks returns the networks of every host, sorted by name then host.
+func (g *Group) Networks() []types.Network {
+	var out []types.Network
+	for _, c := range g.caches {
+		out = append(out, c.Networks()...)
+	}
+	sortNetworks(out)
+	return out
+}
+
+// Network returns the network with the given ID on the given host.
+// If the network is not found, ErrNetworkNotFound is returned.
+func (g *Group) Network(host, id string) (types.Network, error) {
+	c, ok := g.byHost[host]
+	if !ok {
+		return types.Network{}, ErrNetworkNotFound
+	}
+	return c.Network(id)
+}
+
+// NetworksUnused returns the unused networks of every host, sorted by name then host.
+func (g *Group) NetworksUnused() []types.Network {
+	var out []types.Network
+	for _, c := range g.caches {
+		out = append(out, c.NetworksUnused()...)
+	}
+	sortNetworks(out)
+	return out
+}
+
+func sortImages(images []types.Image) {
+	slices.SortFunc(images, func(a, b types.Image) int {
+		if r := strings.Compare(a.Tag(), b.Tag()); r != 0 {
+			return r
+		}
+		return strings.Compare(a.Host, b.Host)
+	})
+}
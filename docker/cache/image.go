@@ -4,7 +4,10 @@ import (
 	"log"
 	"slices"
 	"strings"
+	"sync"
 
+	cerrdefs "github.com/containerd/errdefs"
+	"github.com/docker/docker/api/types/events"
 	"github.com/kdruelle/gmd/docker/types"
 )
 
@@ -77,92 +80,168 @@ func (c *Cache) ImagesUnused() []types.Image {
 
 }
 
-func (c *Cache) refreshImage(id string) {
-	imgs, err := c.cli.ImageList()
-	if err != nil {
-		return
-	}
+// refreshImages applies the given image events, keyed by actor, to the cache,
+// publishes them and returns the changes of the images.
+//
+// A deleted image is removed. Other events (pull, tag, untag, load, import)
+// inspect their actor, which is an image ID or a reference: a tag points to
+// a single image, so the tags of the inspected image are removed from the
+// other images. An untagged image that is gone was deleted right after.
+func (c *Cache) refreshImages(msgs map[string]events.Message) []Change {
+	var changes []Change
+
+	for actor, msg := range msgs {
+		switch msg.Action {
+		case events.ActionPrune:
+			// every pruned image has its own delete event.
+			continue
+		case events.ActionDelete:
+			changes = append(changes, c.apply(func(out *[]Change) {
+				c.removeImage(actor, out)
+			})...)
+			continue
+		}
 
-	c.mu.Lock()
-	defer c.mu.Unlock()
+		inspect, err := c.cli.ImageInspect(c.ctx, actor)
+		if err != nil {
+			log.Printf("refresh image %s after %s: %v", actor, msg.Action, err)
+			if cerrdefs.IsNotFound(err) {
+				changes = append(changes, c.apply(func(out *[]Change) {
+					c.removeImage(actor, out)
+				})...)
+			}
+			continue
+		}
 
-	delete(c.images, id)
+		img := &types.Image{
+			ID:          inspect.ID,
+			RepoTags:    inspect.RepoTags,
+			RepoDigests: inspect.RepoDigests,
+			Size:        inspect.Size,
+			ParentID:    inspect.Parent,
+			Host:        c.host,
+		}
 
-	// faut retrouver l’image par ID
-	for _, img := range imgs {
-		if img.ID == id {
-			c.images[id] = &types.Image{
-				ID:          img.ID,
-				RepoTags:    img.RepoTags,
-				RepoDigests: img.RepoDigests,
-				Size:        img.Size,
-				ParentID:    img.ParentID,
+		changes = append(changes, c.apply(func(out *[]Change) {
+			c.putImage(img, out)
+			for id, other := range c.images {
+				if id == img.ID {
+					continue
+				}
+				tags := slices.DeleteFunc(slices.Clone(other.RepoTags), func(tag string) bool {
+					return slices.Contains(img.RepoTags, tag)
+				})
+				if len(tags) != len(other.RepoTags) {
+					untagged := *other
+					untagged.RepoTags = tags
+					c.putImage(&untagged, out)
+				}
 			}
-			return
-		}
+		})...)
 	}
 
+	return changes
 }
 
-// snapshotImages returns a snapshot of the images in the cache.
-// The function first lists all images with the cli.ImageList() function,
-// then creates a map of the images by ID. It then iterates over the list
-// of images and adds each image to the map, ignoring any images that
-// already exist in the map.
-// Next, it iterates over the list of images again and updates the
-// parents of each image by looking up the parent ID in the map.
-// Finally, it flattens the map into a slice and returns the slice.
-func (c *Cache) snapshotImages() []*types.Image {
-	list, err := c.cli.ImageList()
+// snapshotImages returns a snapshot of the images of the daemon, without
+// the intermediate images, which are read by snapshotHistory.
+// It returns an error if the images could not be listed.
+func (c *Cache) snapshotImages() ([]*types.Image, error) {
+	list, err := c.cli.ImageList(c.ctx)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
-	out := make(map[string]*types.Image)
-
-	addImg := func(id string, tags []string, digs []string, size int64, parent string) {
-		if _, ok := out[id]; ok {
-			return
-		}
-		out[id] = &types.Image{
-			ID:          id,
-			RepoTags:    tags,
-			RepoDigests: digs,
-			Size:        size,
-			ParentID:    parent,
-		}
-	}
-
-	// 1. Add stadards images
+	out := make([]*types.Image, 0, len(list))
 	for _, img := range list {
-		addImg(img.ID, img.RepoTags, img.RepoDigests, img.Size, img.ParentID)
+		out = append(out, &types.Image{
+			ID:          img.ID,
+			RepoTags:    img.RepoTags,
+			RepoDigests: img.RepoDigests,
+			Size:        img.Size,
+			ParentID:    img.ParentID,
+			Host:        c.host,
+		})
 	}
 
-	// 2. Add parents via history
-	for _, img := range list {
-		history, err := c.cli.ImageHistory(img.ID)
-		if err != nil {
-			log.Println("history:", err)
-			continue
-		}
+	c.progress.images.Store(0)
+	c.progress.imagesTotal.Store(int64(len(out)))
+
+	return out, nil
+}
 
-		for _, layer := range history {
-			if layer.ID == "<missing>" || layer.ID == "" {
-				continue
+// snapshotHistory reads the history of the given images in parallel through
+// the cache pool and returns the intermediate images found in it, i.e. the
+// layers that are not one of the given images.
+// The history of an image that could not be read is skipped.
+func (c *Cache) snapshotHistory(images []*types.Image) []*types.Image {
+	var (
+		mu  sync.Mutex
+		out = make(map[string]*types.Image)
+	)
+
+	known := make(map[string]struct{}, len(images))
+	for _, img := range images {
+		known[img.ID] = struct{}{}
+	}
+
+	group := c.pool.NewGroup()
+	for _, img := range images {
+		group.Submit(func() {
+			defer c.progress.images.Add(1)
+			history, err := c.cli.ImageHistory(c.ctx, img.ID)
+			if err != nil {
+				log.Println("history:", err)
+				return
 			}
 
-			if _, ok := out[layer.ID]; !ok {
-				// No tag info → this is an intermediate layer
-				addImg(layer.ID, []string{}, []string{}, layer.Size, "")
+			mu.Lock()
+			defer mu.Unlock()
+			for _, layer := range history {
+				if layer.ID == "<missing>" || layer.ID == "" {
+					continue
+				}
+				if _, ok := known[layer.ID]; ok {
+					continue
+				}
+				if _, ok := out[layer.ID]; !ok {
+					// No tag info → this is an intermediate layer
+					out[layer.ID] = &types.Image{
+						ID:          layer.ID,
+						RepoTags:    []string{},
+						RepoDigests: []string{},
+						Size:        layer.Size,
+						Host:        c.host,
+					}
+				}
 			}
-		}
+		})
 	}
+	_ = group.Wait()
 
-	// 3. Flatten into slice
 	result := make([]*types.Image, 0, len(out))
 	for _, img := range out {
 		result = append(result, img)
 	}
-
 	return result
 }
+
+// loadHistory adds the intermediate images of the given images to the cache
+// and emits an ImagesHistoryLoadedEventType event.
+// Images the cache learnt about in the meantime are kept as is.
+func (c *Cache) loadHistory(images []*types.Image) {
+	layers := c.snapshotHistory(images)
+	if c.ctx.Err() != nil {
+		return
+	}
+
+	c.apply(func(out *[]Change) {
+		for _, layer := range layers {
+			if _, ok := c.images[layer.ID]; !ok {
+				c.putImage(layer, out)
+			}
+		}
+	})
+
+	c.emit(Event{EventType: ImagesHistoryLoadedEventType, Host: c.host})
+}
@@ -0,0 +1,134 @@
+package cache
+
+import (
+	"log"
+	"slices"
+	"strings"
+
+	cerrdefs "github.com/containerd/errdefs"
+	"github.com/docker/docker/api/types/events"
+	"github.com/kdruelle/gmd/docker/types"
+)
+
+// Networks returns the list of networks from the cache, sorted by name.
+// The function locks the cache for reading and returns a copy of the underlying data, so it can be safely used without taking a write lock on the cache.
+func (c *Cache) Networks() []types.Network {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	out := make([]types.Network, 0, len(c.networks))
+	for _, n := range c.networks {
+		out = append(out, *n)
+	}
+	sortNetworks(out)
+	return out
+}
+
+// Network returns the network with the given ID from the cache.
+// The function locks the cache for reading and returns a copy of the underlying data, so it can be safely used without taking a write lock on the cache.
+// If the network is not found, ErrNetworkNotFound is returned.
+func (c *Cache) Network(id string) (types.Network, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if n, ok := c.networks[id]; ok {
+		return *n, nil
+	}
+	return types.Network{}, ErrNetworkNotFound
+}
+
+// NetworksUnused returns the list of unused networks from the cache, sorted by name.
+// A network is considered unused if no container is attached to it. The
+// networks predefined by the daemon are never unused.
+// The function locks the cache for reading and returns a copy of the underlying data, so it can be safely used without taking a write lock on the cache.
+func (c *Cache) NetworksUnused() []types.Network {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	used := make(map[string]bool)
+	for _, cont := range c.containers {
+		if cont.NetworkSettings == nil {
+			continue
+		}
+		for _, endpoint := range cont.NetworkSettings.Networks {
+			if endpoint != nil && endpoint.NetworkID != "" {
+				used[endpoint.NetworkID] = true
+			}
+		}
+	}
+
+	out := make([]types.Network, 0, len(c.networks))
+	for id, n := range c.networks {
+		if !used[id] && len(n.Containers) == 0 && !n.Predefined() {
+			out = append(out, *n)
+		}
+	}
+	sortNetworks(out)
+	return out
+}
+
+// refreshNetwork applies the given network event to the cache and publishes
+// the change of the network, if any.
+// A destroyed network, or a network that cannot be inspected, is removed.
+func (c *Cache) refreshNetwork(ev events.Message) {
+	if ev.Action == events.ActionDestroy || ev.Action == events.ActionRemove {
+		c.apply(func(out *[]Change) {
+			c.removeNetwork(ev.Actor.ID, out)
+		})
+		return
+	}
+
+	n, err := c.cli.NetworkInspect(c.ctx, ev.Actor.ID)
+	c.apply(func(out *[]Change) {
+		if err != nil {
+			log.Printf("refresh network %s, delete network: %v", ev.Actor.ID, err)
+			if cerrdefs.IsNotFound(err) {
+				c.removeNetwork(ev.Actor.ID, out)
+			}
+			return
+		}
+		c.putNetwork(&types.Network{Inspect: n, Host: c.host}, out)
+	})
+}
+
+// snapshotNetworks lists and inspects every network of the daemon, since
+// the list does not carry the containers attached to the networks.
+// The networks are inspected in parallel through the cache pool.
+// It returns an error if the networks could not be listed. A network that
+// could not be inspected is left out of the snapshot.
+func (c *Cache) snapshotNetworks() ([]*types.Network, error) {
+	list, err := c.cli.NetworkList(c.ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	networks := make([]*types.Network, len(list))
+
+	group := c.pool.NewGroup()
+	for i, n := range list {
+		group.Submit(func() {
+			inspect, err := c.cli.NetworkInspect(c.ctx, n.ID)
+			if err != nil {
+				log.Printf("snapshot network %s, skipped: %v", n.ID, err)
+				return
+			}
+			networks[i] = &types.Network{Inspect: inspect, Host: c.host}
+		})
+	}
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+	if err := c.ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return slices.DeleteFunc(networks, func(n *types.Network) bool { return n == nil }), nil
+}
+
+func sortNetworks(networks []types.Network) {
+	slices.SortFunc(networks, func(a, b types.Network) int {
+		if r := strings.Compare(a.Name, b.Name); r != 0 {
+			return r
+		}
+		return strings.Compare(a.Host, b.Host)
+	})
+}
@@ -0,0 +1,80 @@
+package cache
+
+// resync takes a new snapshot of the daemon, applies it to the cache and
+// emits an event for every container, image, volume and network that was
+// added, removed or changed while the cache was not listening.
+func (c *Cache) resync() error {
+	conts, err := c.snapshotContainers()
+	if err != nil {
+		return err
+	}
+	imgs, err := c.snapshotImages()
+	if err != nil {
+		return err
+	}
+	imgs = append(imgs, c.snapshotHistory(imgs)...)
+	vols, err := c.snapshotVolumes()
+	if err != nil {
+		return err
+	}
+	nets, err := c.snapshotNetworks()
+	if err != nil {
+		return err
+	}
+
+	var changes []Change
+
+	c.mu.Lock()
+	seen := make(map[string]struct{}, len(conts))
+	for _, cont := range conts {
+		seen[cont.ID] = struct{}{}
+		c.putContainer(cont, &changes)
+	}
+	for id := range c.containers {
+		if _, ok := seen[id]; !ok {
+			c.removeContainer(id, &changes)
+		}
+	}
+
+	seen = make(map[string]struct{}, len(imgs))
+	for _, img := range imgs {
+		seen[img.ID] = struct{}{}
+		c.putImage(img, &changes)
+	}
+	for id := range c.images {
+		if _, ok := seen[id]; !ok {
+			c.removeImage(id, &changes)
+		}
+	}
+
+	seen = make(map[string]struct{}, len(vols))
+	for _, v := range vols {
+		seen[v.Name] = struct{}{}
+		c.putVolume(v, &changes)
+	}
+	for name := range c.volumes {
+		if _, ok := seen[name]; !ok {
+			c.removeVolume(name, &changes)
+		}
+	}
+
+	seen = make(map[string]struct{}, len(nets))
+	for _, n := range nets {
+		seen[n.ID] = struct{}{}
+		c.putNetwork(n, &changes)
+	}
+	for id := range c.networks {
+		if _, ok := seen[id]; !ok {
+			c.removeNetwork(id, &changes)
+		}
+	}
+	// published before the lock is released, see apply.
+	c.publish(changes)
+	c.mu.Unlock()
+
+	for _, ch := range changes {
+		c.emit(Event{EventType: ch.Type, ActorID: ch.ID, Host: c.host})
+	}
+
+	return nil
+}
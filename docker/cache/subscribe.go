@@ -0,0 +1,301 @@
+package cache
+
+import (
+	"reflect"
+	"slices"
+	"sync"
+
+	"github.com/kdruelle/gmd/docker/types"
+)
+
+// ChangeKind is the kind of a Change.
+type ChangeKind string
+
+const (
+	ChangeAdded   ChangeKind = "added"
+	ChangeUpdated ChangeKind = "updated"
+	ChangeRemoved ChangeKind = "removed"
+)
+
+// Change describes how an object of the cache changed.
+//
+// Old and New hold a types.Container, a types.Image, a types.Volume or a
+// types.Network, according to Type. Volumes are identified by their name.
+// Old is nil when the object was added, New is nil when it was removed.
+type Change struct {
+	Kind ChangeKind
+	Type EventType // Type is ContainerEventType, ImageEventType, VolumeEventType or NetworkEventType.
+	Host string    // Host is the name of the daemon endpoint of the object.
+	ID   string
+	Old  any
+	New  any
+}
+
+// Filter selects the changes delivered to a subscriber.
+// Every empty field matches any value, so the zero Filter matches every change.
+type Filter struct {
+	Hosts []string    // Hosts are the hosts whose changes are delivered.
+	Types []EventType // Types are the object types whose changes are delivered.
+	IDs   []string    // IDs are the objects whose changes are delivered.
+}
+
+// Match reports whether the given change is selected by the filter.
+func (f Filter) Match(ch Change) bool {
+	return (len(f.Hosts) == 0 || slices.Contains(f.Hosts, ch.Host)) &&
+		(len(f.Types) == 0 || slices.Contains(f.Types, ch.Type)) &&
+		(len(f.IDs) == 0 || slices.Contains(f.IDs, ch.ID))
+}
+
+// subscriber queues the changes of a subscription, so a slow consumer never
+// blocks the cache nor the other subscribers.
+type subscriber struct {
+	filter Filter
+	out    chan Change
+	mu     sync.Mutex
+	queue  []Change
+	wake   chan struct{} // wake signals that the queue is not empty.
+	done   chan struct{} // done is closed when the subscription ends.
+	once   sync.Once
+}
+
+func newSubscriber(filter Filter) *subscriber {
+	s := &subscriber{
+		filter: filter,
+		out:    make(chan Change),
+		wake:   make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// push queues the given changes that match the subscriber filter.
+func (s *subscriber) push(changes []Change) {
+	queued := false
+	s.mu.Lock()
+	for _, ch := range changes {
+		if s.filter.Match(ch) {
+			s.queue = append(s.queue, ch)
+			queued = true
+		}
+	}
+	s.mu.Unlock()
+
+	if queued {
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// run delivers the queued changes until the subscription ends, then closes
+// the subscriber channel.
+func (s *subscriber) run() {
+	defer close(s.out)
+	for {
+		s.mu.Lock()
+		if len(s.queue) == 0 {
+			s.mu.Unlock()
+			select {
+			case <-s.wake:
+				continue
+			case <-s.done:
+				return
+			}
+		}
+		ch := s.queue[0]
+		s.queue = s.queue[1:]
+		s.mu.Unlock()
+
+		select {
+		case s.out <- ch:
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// close ends the subscription. It can be called more than once.
+func (s *subscriber) close() {
+	s.once.Do(func() { close(s.done) })
+}
+
+// Subscribe returns a channel receiving the changes of the cache selected by
+// the given filter, in the order they were applied.
+// The channel is closed by Unsubscribe or when the cache is closed.
+func (c *Cache) Subscribe(filter Filter) <-chan Change {
+	return c.subscribe(newSubscriber(filter))
+}
+
+func (c *Cache) subscribe(s *subscriber) <-chan Change {
+	c.subsMu.Lock()
+	defer c.subsMu.Unlock()
+	if c.ctx.Err() != nil {
+		s.close()
+		return s.out
+	}
+	c.subs[s.out] = s
+	return s.out
+}
+
+// Unsubscribe ends the subscription of the given channel and closes it.
+func (c *Cache) Unsubscribe(ch <-chan Change) {
+	c.subsMu.Lock()
+	s, ok := c.subs[ch]
+	delete(c.subs, ch)
+	c.subsMu.Unlock()
+	if ok {
+		s.close()
+	}
+}
+
+// closeSubscriptions ends every subscription of the cache.
+func (c *Cache) closeSubscriptions() {
+	c.subsMu.Lock()
+	defer c.subsMu.Unlock()
+	for ch, s := range c.subs {
+		s.close()
+		delete(c.subs, ch)
+	}
+}
+
+// apply runs fn with the write lock of the cache held and publishes the
+// changes fn records before releasing the lock, so that the subscribers
+// receive the changes in the order they were applied to the cache.
+// It returns the changes.
+func (c *Cache) apply(fn func(out *[]Change)) []Change {
+	var changes []Change
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fn(&changes)
+	c.publish(changes)
+	return changes
+}
+
+// publish delivers the given changes to every subscriber. It never blocks
+// on a subscriber, so it can be called with the lock of the cache held.
+func (c *Cache) publish(changes []Change) {
+	if len(changes) == 0 {
+		return
+	}
+	c.subsMu.Lock()
+	defer c.subsMu.Unlock()
+	for _, s := range c.subs {
+		s.push(changes)
+	}
+}
+
+// putContainer adds or replaces a container of the cache and records the
+// change in out, unless the container did not change.
+// The caller must hold the write lock of the cache.
+func (c *Cache) putContainer(cont *types.Container, out *[]Change) {
+	ch := Change{Kind: ChangeAdded, Type: ContainerEventType, Host: c.host, ID: cont.ID, New: *cont}
+	if old, ok := c.containers[cont.ID]; ok {
+		if reflect.DeepEqual(old, cont) {
+			return
+		}
+		ch.Kind, ch.Old = ChangeUpdated, *old
+	}
+	c.containers[cont.ID] = cont
+	*out = append(*out, ch)
+}
+
+// removeContainer removes a container from the cache and records the change
+// in out, if the container was in the cache.
+// The caller must hold the write lock of the cache.
+func (c *Cache) removeContainer(id string, out *[]Change) {
+	old, ok := c.containers[id]
+	if !ok {
+		return
+	}
+	delete(c.containers, id)
+	*out = append(*out, Change{Kind: ChangeRemoved, Type: ContainerEventType, Host: c.host, ID: id, Old: *old})
+}
+
+// putImage adds or replaces an image of the cache and records the change in
+// out, unless the image did not change.
+// The caller must hold the write lock of the cache.
+func (c *Cache) putImage(img *types.Image, out *[]Change) {
+	ch := Change{Kind: ChangeAdded, Type: ImageEventType, Host: c.host, ID: img.ID, New: *img}
+	if old, ok := c.images[img.ID]; ok {
+		if imageEqual(old, img) {
+			return
+		}
+		ch.Kind, ch.Old = ChangeUpdated, *old
+	}
+	c.images[img.ID] = img
+	*out = append(*out, ch)
+}
+
+// removeImage removes an image from the cache and records the change in out,
+// if the image was in the cache.
+// The caller must hold the write lock of the cache.
+func (c *Cache) removeImage(id string, out *[]Change) {
+	old, ok := c.images[id]
+	if !ok {
+		return
+	}
+	delete(c.images, id)
+	*out = append(*out, Change{Kind: ChangeRemoved, Type: ImageEventType, Host: c.host, ID: id, Old: *old})
+}
+
+// imageEqual reports whether two images carry the same information.
+func imageEqual(a, b *types.Image) bool {
+	return a.Size == b.Size && a.ParentID == b.ParentID &&
+		slices.Equal(a.RepoTags, b.RepoTags) && slices.Equal(a.RepoDigests, b.RepoDigests)
+}
+
+// putVolume adds or replaces a volume of the cache and records the change in
+// out, unless the volume did not change.
+// The caller must hold the write lock of the cache.
+func (c *Cache) putVolume(v *types.Volume, out *[]Change) {
+	ch := Change{Kind: ChangeAdded, Type: VolumeEventType, Host: c.host, ID: v.Name, New: *v}
+	if old, ok := c.volumes[v.Name]; ok {
+		if reflect.DeepEqual(old, v) {
+			return
+		}
+		ch.Kind, ch.Old = ChangeUpdated, *old
+	}
+	c.volumes[v.Name] = v
+	*out = append(*out, ch)
+}
+
+// removeVolume removes a volume from the cache and records the change in
+// out, if the volume was in the cache.
+// The caller must hold the write lock of the cache.
+func (c *Cache) removeVolume(name string, out *[]Change) {
+	old, ok := c.volumes[name]
+	if !ok {
+		return
+	}
+	delete(c.volumes, name)
+	*out = append(*out, Change{Kind: ChangeRemoved, Type: VolumeEventType, Host: c.host, ID: name, Old: *old})
+}
+
+// putNetwork adds or replaces a network of the cache and records the change
+// in out, unless the network did not change.
+// The caller must hold the write lock of the cache.
+func (c *Cache) putNetwork(n *types.Network, out *[]Change) {
+	ch := Change{Kind: ChangeAdded, Type: NetworkEventType, Host: c.host, ID: n.ID, New: *n}
+	if old, ok := c.networks[n.ID]; ok {
+		if reflect.DeepEqual(old, n) {
+			return
+		}
+		ch.Kind, ch.Old = ChangeUpdated, *old
+	}
+	c.networks[n.ID] = n
+	*out = append(*out, ch)
+}
+
+// removeNetwork removes a network from the cache and records the change in
+// out, if the network was in the cache.
+// The caller must hold the write lock of the cache.
+func (c *Cache) removeNetwork(id string, out *[]Change) {
+	old, ok := c.networks[id]
+	if !ok {
+		return
+	}
+	delete(c.networks, id)
+	*out = append(*out, Change{Kind: ChangeRemoved, Type: NetworkEventType, Host: c.host, ID: id, Old: *old})
+}
@@ -0,0 +1,114 @@
+package cache
+
+import (
+	"log"
+	"slices"
+	"strings"
+
+	cerrdefs "github.com/containerd/errdefs"
+	"github.com/docker/docker/api/types/events"
+	"github.com/kdruelle/gmd/docker/types"
+)
+
+// Volumes returns the list of volumes from the cache, sorted by name.
+// The function locks the cache for reading and returns a copy of the underlying data, so it can be safely used without taking a write lock on the cache.
+func (c *Cache) Volumes() []types.Volume {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	out := make([]types.Volume, 0, len(c.volumes))
+	for _, v := range c.volumes {
+		out = append(out, *v)
+	}
+	sortVolumes(out)
+	return out
+}
+
+// Volume returns the volume with the given name from the cache.
+// The function locks the cache for reading and returns a copy of the underlying data, so it can be safely used without taking a write lock on the cache.
+// If the volume is not found, ErrVolumeNotFound is returned.
+func (c *Cache) Volume(name string) (types.Volume, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if v, ok := c.volumes[name]; ok {
+		return *v, nil
+	}
+	return types.Volume{}, ErrVolumeNotFound
+}
+
+// VolumesUnused returns the list of unused volumes from the cache, sorted by name.
+// A volume is considered unused if no container mounts it.
+// The function locks the cache for reading and returns a copy of the underlying data, so it can be safely used without taking a write lock on the cache.
+func (c *Cache) VolumesUnused() []types.Volume {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	used := make(map[string]bool)
+	for _, cont := range c.containers {
+		for _, m := range cont.Mounts {
+			if m.Name != "" {
+				used[m.Name] = true
+			}
+		}
+	}
+
+	out := make([]types.Volume, 0, len(c.volumes))
+	for name, v := range c.volumes {
+		if !used[name] {
+			out = append(out, *v)
+		}
+	}
+	sortVolumes(out)
+	return out
+}
+
+// refreshVolume applies the given volume event to the cache and publishes
+// the change of the volume, if any.
+// A destroyed volume, or a volume that cannot be inspected, is removed.
+func (c *Cache) refreshVolume(ev events.Message) {
+	if ev.Action == events.ActionDestroy {
+		c.apply(func(out *[]Change) {
+			c.removeVolume(ev.Actor.ID, out)
+		})
+		return
+	}
+
+	vol, err := c.cli.VolumeInspect(c.ctx, ev.Actor.ID)
+	c.apply(func(out *[]Change) {
+		if err != nil {
+			log.Printf("refresh volume %s, delete volume: %v", ev.Actor.ID, err)
+			if cerrdefs.IsNotFound(err) {
+				c.removeVolume(ev.Actor.ID, out)
+			}
+			return
+		}
+		c.putVolume(&types.Volume{Volume: vol, Host: c.host}, out)
+	})
+}
+
+// snapshotVolumes lists every volume of the daemon.
+// It returns an error if the volumes could not be listed.
+func (c *Cache) snapshotVolumes() ([]*types.Volume, error) {
+	vols, err := c.cli.VolumeList(c.ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]*types.Volume, 0, len(vols))
+	for _, v := range vols {
+		if v == nil {
+			continue
+		}
+		out = append(out, &types.Volume{Volume: *v, Host: c.host})
+	}
+	return out, nil
+}
+
+func sortVolumes(volumes []types.Volume) {
+	slices.SortFunc(volumes, func(a, b types.Volume) int {
+		if r := strings.Compare(a.Name, b.Name); r != 0 {
+			return r
+		}
+		return strings.Compare(a.Host, b.Host)
+	})
+}
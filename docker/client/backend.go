@@ -0,0 +1,163 @@
+package client
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/client"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// Kind is the kind of container engine behind a client.
+type Kind string
+
+const (
+	DockerKind Kind = "docker"
+	PodmanKind Kind = "podman"
+)
+
+// Backend is the set of engine operations gmd relies on: listing,
+// inspecting, events, stats, pulling, creating and removing objects.
+//
+// The Docker SDK client implements Backend as is. Other engines implement it
+// on top of their own API and translate their behaviour to Docker's.
+type Backend interface {
+	ServerVersion(ctx context.Context) (types.Version, error)
+
+	ContainerList(ctx context.Context, options container.ListOptions) ([]container.Summary, error)
+	ContainerInspect(ctx context.Context, containerID string) (container.InspectResponse, error)
+	ContainerCreate(ctx context.Context, config *container.Config, hostConfig *container.HostConfig, networkingConfig *network.NetworkingConfig, platform *ocispec.Platform, containerName string) (container.CreateResponse, error)
+	ContainerStart(ctx context.Context, containerID string, options container.StartOptions) error
+	ContainerStop(ctx context.Context, containerID string, options container.StopOptions) error
+	ContainerRestart(ctx context.Context, containerID string, options container.StopOptions) error
+	ContainerRemove(ctx context.Context, containerID string, options container.RemoveOptions) error
+	ContainerRename(ctx context.Context, containerID, newContainerName string) error
+	ContainerExecCreate(ctx context.Context, containerID string, options container.ExecOptions) (container.ExecCreateResponse, error)
+	ContainerExecAttach(ctx context.Context, execID string, config container.ExecAttachOptions) (types.HijackedResponse, error)
+	ContainerExecInspect(ctx context.Context, execID string) (container.ExecInspect, error)
+	ContainerStatsOneShot(ctx context.Context, containerID string) (container.StatsResponseReader, error)
+
+	ImageList(ctx context.Context, options image.ListOptions) ([]image.Summary, error)
+	ImageInspect(ctx context.Context, imageID string, inspectOpts ...client.ImageInspectOption) (image.InspectResponse, error)
+	ImageHistory(ctx context.Context, imageID string, historyOpts ...client.ImageHistoryOption) ([]image.HistoryResponseItem, error)
+	ImagePull(ctx context.Context, refStr string, options image.PullOptions) (io.ReadCloser, error)
+	ImageRemove(ctx context.Context, imageID string, options image.RemoveOptions) ([]image.DeleteResponse, error)
+	ImageTag(ctx context.Context, source, target string) error
+
+	VolumeList(ctx context.Context, options volume.ListOptions) (volume.ListResponse, error)
+	VolumeInspect(ctx context.Context, volumeID string) (volume.Volume, error)
+	VolumeCreate(ctx context.Context, options volume.CreateOptions) (volume.Volume, error)
+	VolumeRemove(ctx context.Context, volumeID string, force bool) error
+	DiskUsage(ctx context.Context, options types.DiskUsageOptions) (types.DiskUsage, error)
+
+	NetworkList(ctx context.Context, options network.ListOptions) ([]network.Summary, error)
+	NetworkInspect(ctx context.Context, networkID string, options network.InspectOptions) (network.Inspect, error)
+	NetworkCreate(ctx context.Context, name string, options network.CreateOptions) (network.CreateResponse, error)
+	NetworkRemove(ctx context.Context, networkID string) error
+	NetworkConnect(ctx context.Context, networkID, containerID string, config *network.EndpointSettings) error
+	NetworkDisconnect(ctx context.Context, networkID, containerID string, force bool) error
+
+	Events(ctx context.Context, options events.ListOptions) (<-chan events.Message, <-chan error)
+
+	Close() error
+}
+
+// podmanBackend drives Podman through the Docker compatible API of its REST
+// socket, once podman is set. Its scope is narrow: only the names of the
+// events differ from Docker's, every other call goes through the Docker
+// compatible API as is.
+type podmanBackend struct {
+	Backend
+	podman *atomic.Bool // podman is set once the engine is detected as Podman, see Client.Ping.
+}
+
+// Events normalizes Podman events to Docker's: Podman reports image
+// removals as "remove" where Docker reports "delete", and container deaths
+// as "died" where Docker reports "die". The filters are widened accordingly.
+func (p podmanBackend) Events(ctx context.Context, options events.ListOptions) (<-chan events.Message, <-chan error) {
+	if !p.podman.Load() {
+		return p.Backend.Events(ctx, options)
+	}
+	if options.Filters.Contains("event") && options.Filters.ExactMatch("event", string(events.ActionDie)) {
+		options.Filters.Add("event", "died")
+	}
+
+	in, errs := p.Backend.Events(ctx, options)
+	out := make(chan events.Message)
+
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case msg, ok := <-in:
+				if !ok {
+					return
+				}
+				switch {
+				case msg.Type == events.ImageEventType && msg.Action == events.ActionRemove:
+					msg.Action = events.ActionDelete
+				case msg.Type == events.ContainerEventType && msg.Action == "died":
+					msg.Action = events.ActionDie
+				}
+				select {
+				case out <- msg:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out, errs
+}
+
+// isPodman reports whether the given version was reported by Podman, which
+// names its engine "Podman Engine" in the Docker compatible API.
+func isPodman(v types.Version) bool {
+	if strings.Contains(v.Platform.Name, "Podman") {
+		return true
+	}
+	for _, c := range v.Components {
+		if c.Name == "Podman Engine" {
+			return true
+		}
+	}
+	return false
+}
+
+// localSockets returns the local engine sockets gmd looks for when no daemon
+// is configured, in order of preference: rootful Docker, rootless Docker,
+// rootless Podman, rootful Podman.
+func localSockets() []string {
+	sockets := []string{"/var/run/docker.sock"}
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+		sockets = append(sockets,
+			filepath.Join(runtimeDir, "docker.sock"),
+			filepath.Join(runtimeDir, "podman", "podman.sock"),
+		)
+	}
+	return append(sockets, "/run/podman/podman.sock")
+}
+
+// detectLocalHost returns the URL of the first local engine socket found,
+// or the Docker default host if none is found.
+func detectLocalHost() string {
+	for _, socket := range localSockets() {
+		if info, err := os.Stat(socket); err == nil && info.Mode()&os.ModeSocket != 0 {
+			return "unix://" + socket
+		}
+	}
+	return client.DefaultDockerHost
+}
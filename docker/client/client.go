@@ -2,8 +2,15 @@ package client
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
 
+	"github.com/docker/cli/cli/connhelper"
 	"github.com/docker/docker/client"
+	"github.com/docker/go-connections/tlsconfig"
 )
 
 // Client represents a client to the Docker daemon.
@@ -11,20 +18,184 @@ import (
 // It provides a way to interact with the daemon and receive events
 // from the daemon.
 type Client struct {
-	cli           client.APIClient   // cli is the underlying client to the Docker daemon.
+	cli           Backend            // cli is the underlying client to the container engine.
+	endpoint      Endpoint           // endpoint is the daemon endpoint the client is connected to.
+	timeouts      Timeouts           // timeouts bounds the duration of each kind of operation.
+	eventsMu      sync.Mutex         // eventsMu guards eventsContext and eventsCancel, set by StartEvents while StopEvents may run.
 	eventsContext context.Context    // eventsContext is the context used for listening to events from the daemon.
 	eventsCancel  context.CancelFunc // eventsCancel is the cancel function for the events context.
+	podman        atomic.Bool        // podman is set when Ping detected Podman.
 }
 
-// NewClient returns a new Client object, which represents a client to the Docker daemon.
+// Options selects the Docker daemons to connect to.
+//
+// Every host and every context selects one daemon. When both are empty,
+// the daemon is selected like the docker CLI does: DOCKER_HOST, then
+// DOCKER_CONTEXT, then the current context of the docker CLI configuration.
+type Options struct {
+	Hosts     []string // Hosts are daemon URLs (unix://, tcp://, ssh://).
+	Contexts  []string // Contexts are names of docker CLI contexts.
+	TLSVerify bool     // TLSVerify enables the server certificate verification for Hosts.
+	TLSCACert string   // TLSCACert is the CA certificate used with Hosts.
+	TLSCert   string   // TLSCert is the client certificate used with Hosts.
+	TLSKey    string   // TLSKey is the client key used with Hosts.
+	Timeouts  Timeouts // Timeouts bounds the duration of each kind of operation.
+}
+
+// ResolveEndpoints returns the endpoints selected by the given options,
+// hosts first, then contexts.
+func ResolveEndpoints(opts Options) ([]Endpoint, error) {
+	out := make([]Endpoint, 0, len(opts.Hosts)+len(opts.Contexts))
+
+	for _, host := range opts.Hosts {
+		out = append(out, Endpoint{
+			Name:          host,
+			Host:          host,
+			TLSVerify:     opts.TLSVerify,
+			SkipTLSVerify: !opts.TLSVerify,
+			CAFile:        opts.TLSCACert,
+			CertFile:      opts.TLSCert,
+			KeyFile:       opts.TLSKey,
+		})
+	}
+
+	for _, name := range opts.Contexts {
+		e, err := LoadContext(name)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+
+	if len(out) > 0 {
+		return out, nil
+	}
+
+	name := DefaultContextName
+	if os.Getenv(client.EnvOverrideHost) == "" {
+		name = currentContext()
+	}
+	e, err := LoadContext(name)
+	if err != nil {
+		return nil, err
+	}
+	return []Endpoint{e}, nil
+}
+
+// NewClients returns a new Client object for every daemon selected by the
+// given options.
+// If the creation of a client fails, it returns nil and an error.
+func NewClients(opts Options) ([]*Client, error) {
+	endpoints, err := ResolveEndpoints(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]*Client, 0, len(endpoints))
+	for _, e := range endpoints {
+		cli, err := NewClientFromEndpoint(e, opts.Timeouts)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", e.Name, err)
+		}
+		out = append(out, cli)
+	}
+	return out, nil
+}
+
+// NewClientFromEndpoint returns a new Client object connected to the given endpoint.
+// ssh:// endpoints go through "docker system dial-stdio" on the remote host,
+// tcp:// endpoints use TLS when the endpoint requires the verification of
+// the server, with the system roots if it has no CA, or carries TLS material.
 // If the creation of the client fails, it returns nil and an error.
-func NewClient() (*Client, error) {
-	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+func NewClientFromEndpoint(endpoint Endpoint, timeouts Timeouts) (*Client, error) {
+	opts := []client.Opt{client.WithAPIVersionNegotiation()}
+
+	helper, err := connhelper.GetConnectionHelper(endpoint.Host)
 	if err != nil {
 		return nil, err
 	}
 
-	return &Client{
-		cli: cli,
-	}, nil
+	switch {
+	case helper != nil:
+		opts = append(opts,
+			client.WithHTTPClient(&http.Client{Transport: &http.Transport{DialContext: helper.Dialer}}),
+			client.WithHost(helper.Host),
+			client.WithDialContext(helper.Dialer),
+		)
+	case endpoint.TLS():
+		tlsc, err := tlsconfig.Client(tlsconfig.Options{
+			CAFile:             endpoint.CAFile,
+			CertFile:           endpoint.CertFile,
+			KeyFile:            endpoint.KeyFile,
+			InsecureSkipVerify: endpoint.SkipTLSVerify && !endpoint.TLSVerify,
+			ExclusiveRootPools: true,
+		})
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts,
+			client.WithHTTPClient(&http.Client{
+				Transport:     &http.Transport{TLSClientConfig: tlsc},
+				CheckRedirect: client.CheckRedirect,
+			}),
+			client.WithHost(endpoint.Host),
+		)
+	default:
+		opts = append(opts, client.WithHost(endpoint.Host))
+	}
+
+	cli, err := client.NewClientWithOpts(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewClientWithBackend(cli, endpoint, timeouts), nil
+}
+
+// NewClientWithBackend returns a new Client object using the given backend
+// to reach the given endpoint. The backend is adapted to Podman once Ping
+// detects it.
+func NewClientWithBackend(backend Backend, endpoint Endpoint, timeouts Timeouts) *Client {
+	c := &Client{
+		endpoint: endpoint,
+		timeouts: timeouts,
+	}
+	c.cli = podmanBackend{Backend: backend, podman: &c.podman}
+	return c
+}
+
+// Endpoint returns the endpoint the client is connected to.
+func (c *Client) Endpoint() Endpoint {
+	return c.endpoint
+}
+
+// Close stops the event subscription and closes the connections of the
+// client to the daemon. The client must not be used afterwards.
+// It returns an error if the backend could not be closed.
+func (c *Client) Close() error {
+	c.StopEvents()
+	return c.cli.Close()
+}
+
+// Ping checks that the daemon answers, and detects from its version whether
+// it is Podman, see Kind.
+// It returns an error if the daemon could not be reached.
+func (c *Client) Ping(ctx context.Context) error {
+	ctx, cancel := withTimeout(ctx, c.timeouts.Default)
+	defer cancel()
+	v, err := c.cli.ServerVersion(ctx)
+	if err != nil {
+		return err
+	}
+	c.podman.Store(isPodman(v))
+	return nil
+}
+
+// Kind returns the engine behind the client, as detected by the last Ping.
+// It is DockerKind until then.
+func (c *Client) Kind() Kind {
+	if c.podman.Load() {
+		return PodmanKind
+	}
+	return DockerKind
 }
@@ -0,0 +1,144 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/kdruelle/gmd/docker/types"
+)
+
+// Labels set by docker compose on the containers it creates, describing
+// where the project comes from. The project and service labels are in the
+// types package.
+const (
+	composeWorkingDirLabel = "com.docker.compose.project.working_dir"
+	composeConfigLabel     = "com.docker.compose.project.config_files"
+	composeEnvFileLabel    = "com.docker.compose.project.environment_file"
+	composeOneoffLabel     = "com.docker.compose.oneoff"
+)
+
+// ComposeService is a service of a compose project, as recorded in the labels
+// of the containers docker compose creates for it.
+type ComposeService struct {
+	Project     string   // Project is the name of the compose project.
+	Service     string   // Service is the name of the service in the project.
+	WorkingDir  string   // WorkingDir is the directory the project was started from.
+	ConfigFiles []string // ConfigFiles are the compose files of the project, in order.
+	EnvFiles    []string // EnvFiles are the environment files of the project, if any.
+}
+
+// ComposeServiceOf returns the compose service of the given container.
+// It reports false if the container was not created by docker compose, was
+// created by `compose run`, or if the working directory or one of the files
+// of its project cannot be read, in which case the service cannot be
+// recreated by docker compose.
+func ComposeServiceOf(config container.InspectResponse) (ComposeService, bool) {
+	if config.Config == nil {
+		return ComposeService{}, false
+	}
+	labels := config.Config.Labels
+
+	s := ComposeService{
+		Project:    labels[types.ComposeProjectLabel],
+		Service:    labels[types.ComposeServiceLabel],
+		WorkingDir: labels[composeWorkingDirLabel],
+	}
+	if s.Project == "" || s.Service == "" || s.WorkingDir == "" || labels[composeOneoffLabel] == "True" {
+		return ComposeService{}, false
+	}
+	s.ConfigFiles = splitComposeFiles(labels[composeConfigLabel], s.WorkingDir)
+	s.EnvFiles = splitComposeFiles(labels[composeEnvFileLabel], s.WorkingDir)
+	if len(s.ConfigFiles) == 0 {
+		return ComposeService{}, false
+	}
+
+	if info, err := os.Stat(s.WorkingDir); err != nil || !info.IsDir() {
+		return ComposeService{}, false
+	}
+	for _, f := range slices.Concat(s.ConfigFiles, s.EnvFiles) {
+		fd, err := os.Open(f)
+		if err != nil {
+			return ComposeService{}, false
+		}
+		fd.Close()
+	}
+
+	return s, true
+}
+
+// splitComposeFiles splits a comma separated list of files of a compose
+// label. Relative files are relative to the working directory.
+func splitComposeFiles(label, workingDir string) []string {
+	var files []string
+	for _, f := range strings.Split(label, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		if !filepath.IsAbs(f) {
+			f = filepath.Join(workingDir, f)
+		}
+		files = append(files, f)
+	}
+	return files
+}
+
+// ComposeArgs returns the arguments of the docker CLI recreating the given
+// service against the daemon of the client, like
+// `docker compose up -d --no-deps <service>` run from the project directory.
+func (c *Client) ComposeArgs(s ComposeService) []string {
+	args := slices.Clone(c.Endpoint().CLIArgs())
+	args = append(args, "compose", "--project-name", s.Project, "--project-directory", s.WorkingDir)
+	for _, f := range s.ConfigFiles {
+		args = append(args, "--file", f)
+	}
+	for _, f := range s.EnvFiles {
+		args = append(args, "--env-file", f)
+	}
+	return append(args, "up", "--detach", "--no-deps", s.Service)
+}
+
+// ComposeUp recreates the given service with docker compose, as
+// `docker compose up -d --no-deps <service>` would.
+// It returns the output of docker compose, and an error if docker compose
+// failed or could not be run.
+func (c *Client) ComposeUp(ctx context.Context, s ComposeService) ([]byte, error) {
+	return c.composeUp(ctx, s, c.ComposeArgs(s))
+}
+
+// ComposeRecreate recreates the given service with docker compose even if
+// its configuration did not change, as
+// `docker compose up -d --no-deps --force-recreate <service>` would.
+// It returns the output of docker compose, and an error if docker compose
+// failed or could not be run.
+func (c *Client) ComposeRecreate(ctx context.Context, s ComposeService) ([]byte, error) {
+	args := c.ComposeArgs(s)
+	args = slices.Insert(args, len(args)-1, "--force-recreate")
+	return c.composeUp(ctx, s, args)
+}
+
+// composeUp runs the docker CLI with the given arguments from the project
+// directory of the given service.
+func (c *Client) composeUp(ctx context.Context, s ComposeService, args []string) ([]byte, error) {
+	ctx, cancel := withTimeout(ctx, c.timeouts.Stop)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "docker", args...)
+	cmd.Dir = s.WorkingDir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return out, fmt.Errorf("docker compose up %s: %w", s.Service, err)
+	}
+	return out, nil
+}
+
+// ComposeAvailable reports whether the docker compose plugin can be run.
+func ComposeAvailable(ctx context.Context) bool {
+	return exec.CommandContext(ctx, "docker", "compose", "version").Run() == nil
+}
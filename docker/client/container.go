@@ -3,10 +3,13 @@ package client
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log"
+	"sort"
 	"strings"
 
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
 	"github.com/docker/docker/api/types/network"
 	"github.com/hashicorp/go-version"
 )
@@ -16,39 +19,60 @@ import (
 // The returned list of containers is a slice of container.Summary objects.
 // The container.Summary objects contain only the most basic information about the container, such as its ID, name, and status.
 // The container.Summary objects are returned in a random order.
-func (c *Client) ContainerList() ([]container.Summary, error) {
-	return c.cli.ContainerList(context.Background(), container.ListOptions{All: true})
+func (c *Client) ContainerList(ctx context.Context) ([]container.Summary, error) {
+	ctx, cancel := withTimeout(ctx, c.timeouts.Default)
+	defer cancel()
+	return c.cli.ContainerList(ctx, container.ListOptions{All: true})
 }
 
 // StartContainer starts a container with the given ID.
 // It returns an error if the container could not be started.
-func (c *Client) StartContainer(id string) error {
-	return c.cli.ContainerStart(context.Background(), id, container.StartOptions{})
+func (c *Client) StartContainer(ctx context.Context, id string) error {
+	ctx, cancel := withTimeout(ctx, c.timeouts.Default)
+	defer cancel()
+	return c.cli.ContainerStart(ctx, id, container.StartOptions{})
 }
 
 // StopContainer stops a container with the given ID.
 // It returns an error if the container could not be stopped.
-func (c *Client) StopContainer(id string) error {
-	return c.cli.ContainerStop(context.Background(), id, container.StopOptions{})
+func (c *Client) StopContainer(ctx context.Context, id string) error {
+	ctx, cancel := withTimeout(ctx, c.timeouts.Stop)
+	defer cancel()
+	return c.cli.ContainerStop(ctx, id, container.StopOptions{})
 }
 
 // RestartContainer restarts a container with the given ID.
 // It returns an error if the container could not be restarted.
-func (c *Client) RestartContainer(id string) error {
-	return c.cli.ContainerRestart(context.Background(), id, container.StopOptions{})
+func (c *Client) RestartContainer(ctx context.Context, id string) error {
+	ctx, cancel := withTimeout(ctx, c.timeouts.Stop)
+	defer cancel()
+	return c.cli.ContainerRestart(ctx, id, container.StopOptions{})
 }
 
 // DeleteContainer deletes a container with the given ID.
 // It returns an error if the container could not be deleted.
-func (c *Client) DeleteContainer(id string) error {
+func (c *Client) DeleteContainer(ctx context.Context, id string) error {
+	ctx, cancel := withTimeout(ctx, c.timeouts.Default)
+	defer cancel()
 	dockerOpts := container.RemoveOptions{}
-	return c.cli.ContainerRemove(context.Background(), id, dockerOpts)
+	return c.cli.ContainerRemove(ctx, id, dockerOpts)
+}
+
+// RenameContainer renames the container with the given ID.
+// It returns an error if the container could not be renamed, e.g. when the
+// name is already taken.
+func (c *Client) RenameContainer(ctx context.Context, id, name string) error {
+	ctx, cancel := withTimeout(ctx, c.timeouts.Default)
+	defer cancel()
+	return c.cli.ContainerRename(ctx, id, name)
 }
 
 // ContainerInspect returns the configuration of the container with the given ID.
 // It returns an error if the container could not be inspected.
-func (c *Client) ContainerInspect(id string) (container.InspectResponse, error) {
-	return c.cli.ContainerInspect(context.Background(), id)
+func (c *Client) ContainerInspect(ctx context.Context, id string) (container.InspectResponse, error) {
+	ctx, cancel := withTimeout(ctx, c.timeouts.Default)
+	defer cancel()
+	return c.cli.ContainerInspect(ctx, id)
 }
 
 // ContainerStats returns the stats of a container with the given ID.
@@ -56,13 +80,17 @@ func (c *Client) ContainerInspect(id string) (container.InspectResponse, error)
 // The returned stats are the result of a single shot stats query, and are not
 // streamed. If the container is not running, the stats will be empty.
 // If the container does not exist, an error will be returned.
-func (c *Client) ContainerStats(id string) (container.StatsResponse, error) {
+func (c *Client) ContainerStats(ctx context.Context, id string) (container.StatsResponse, error) {
+	ctx, cancel := withTimeout(ctx, c.timeouts.Default)
+	defer cancel()
+
 	var v container.StatsResponse
-	stats, err := c.cli.ContainerStatsOneShot(context.Background(), id)
+	stats, err := c.cli.ContainerStatsOneShot(ctx, id)
 
 	if err != nil {
 		return v, err
 	}
+	defer stats.Body.Close()
 
 	dec := json.NewDecoder(stats.Body)
 	err = dec.Decode(&v)
@@ -74,24 +102,202 @@ func (c *Client) ContainerStats(id string) (container.StatsResponse, error) {
 // The given container configuration is expected to be a container.InspectResponse object.
 // The created container will have the same configuration as the given container.
 // The function will sanitize the given container configuration to make it compatible with
-// the docker daemon API version.
+// the docker daemon API version, see CreateConfig.
 // The function will return a container.CreateResponse object containing information about the created container.
-func (c *Client) CreateContainerFromConfig(config container.InspectResponse) (container.CreateResponse, error) {
-
-	info, err := c.cli.ServerVersion(context.Background())
+func (c *Client) CreateContainerFromConfig(ctx context.Context, config container.InspectResponse) (container.CreateResponse, error) {
+	config, err := c.CreateConfig(ctx, config)
 	if err != nil {
-		log.Fatal("Failed to get docker version")
 		return container.CreateResponse{}, err
 	}
 
-	sanitizeContainerJONVersion(&config, info.APIVersion)
-
+	primary, others := splitNetworks(config)
 	netConfig := &network.NetworkingConfig{
-		EndpointsConfig: config.NetworkSettings.Networks,
+		EndpointsConfig: primary,
+	}
+
+	createCtx, cancel := withTimeout(ctx, c.timeouts.Default)
+	defer cancel()
+
+	r, err := c.cli.ContainerCreate(createCtx, config.Config, config.HostConfig, netConfig, nil, config.Name)
+	if err != nil {
+		return r, err
+	}
+
+	for _, name := range sortedNetworks(others) {
+		if err := c.ConnectNetwork(ctx, name, r.ID, others[name]); err != nil {
+			if rmErr := c.DeleteContainer(ctx, r.ID); rmErr != nil {
+				log.Printf("remove container %s after failed network connect: %v", r.ID, rmErr)
+			}
+			return container.CreateResponse{}, fmt.Errorf("failed to connect network %s: %w", name, err)
+		}
+	}
+
+	return r, nil
+}
+
+// SecondaryNetworks returns the names of the networks CreateContainerFromConfig
+// connects a container created from the given configuration to once it is
+// created, in the order they are connected. See splitNetworks.
+func SecondaryNetworks(config container.InspectResponse) []string {
+	_, others := splitNetworks(config)
+	return sortedNetworks(others)
+}
+
+// sortedNetworks returns the names of the given networks, sorted.
+func sortedNetworks(networks map[string]*network.EndpointSettings) []string {
+	names := make([]string, 0, len(networks))
+	for name := range networks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// splitNetworks returns the endpoint of the given container on its primary
+// network, the one it is created on, and its endpoints on the other networks,
+// which are connected once it is created: older API versions reject creating
+// a container with more than one endpoint.
+// The primary network is the network mode of the container when it names one
+// of its networks, the first network by name otherwise. Containers sharing
+// the network stack of the host or of another container keep their endpoints
+// as is. Only the settings a user can choose are kept (IP addresses, aliases,
+// links, ...), without the alias docker adds after the ID of the container.
+func splitNetworks(config container.InspectResponse) (primary, others map[string]*network.EndpointSettings) {
+	if config.NetworkSettings == nil || len(config.NetworkSettings.Networks) == 0 {
+		return nil, nil
+	}
+	networks := config.NetworkSettings.Networks
+
+	mode := container.NetworkMode("")
+	if config.HostConfig != nil {
+		mode = config.HostConfig.NetworkMode
+	}
+	if mode.IsHost() || mode.IsNone() || mode.IsContainer() {
+		return networks, nil
+	}
+
+	primaryName := mode.NetworkName()
+	if _, ok := networks[primaryName]; !ok {
+		names := make([]string, 0, len(networks))
+		for name := range networks {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		primaryName = names[0]
+	}
+
+	shortID := config.ID
+	if len(shortID) > 12 {
+		shortID = shortID[:12]
+	}
+
+	primary = make(map[string]*network.EndpointSettings, 1)
+	others = make(map[string]*network.EndpointSettings, len(networks)-1)
+	for name, ep := range networks {
+		if ep == nil {
+			ep = &network.EndpointSettings{}
+		}
+		settings := &network.EndpointSettings{
+			IPAMConfig: ep.IPAMConfig,
+			Links:      ep.Links,
+			MacAddress: ep.MacAddress,
+			DriverOpts: ep.DriverOpts,
+			GwPriority: ep.GwPriority,
+		}
+		for _, alias := range ep.Aliases {
+			if shortID == "" || alias != shortID {
+				settings.Aliases = append(settings.Aliases, alias)
+			}
+		}
+		if name == primaryName {
+			primary[name] = settings
+		} else {
+			others[name] = settings
+		}
+	}
+	return primary, others
+}
+
+// CreateConfig returns the configuration CreateContainerFromConfig sends to
+// the daemon to recreate a container from the given configuration: a copy of
+// it, sanitized for the API version of the daemon. The given configuration is
+// left untouched.
+// It returns an error if the daemon version could not be read.
+func (c *Client) CreateConfig(ctx context.Context, config container.InspectResponse) (container.InspectResponse, error) {
+	ctx, cancel := withTimeout(ctx, c.timeouts.Default)
+	defer cancel()
+
+	info, err := c.cli.ServerVersion(ctx)
+	if err != nil {
+		return container.InspectResponse{}, fmt.Errorf("failed to get docker version: %w", err)
+	}
+
+	// sanitizing modifies the maps and pointers of the configuration.
+	raw, err := json.Marshal(config)
+	if err != nil {
+		return container.InspectResponse{}, err
 	}
+	var out container.InspectResponse
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return container.InspectResponse{}, err
+	}
+
+	sanitizeContainerJONVersion(&out, info.APIVersion)
+	reattachAnonymousVolumes(&out)
+	return out, nil
+}
 
-	r, err := c.cli.ContainerCreate(context.Background(), config.Config, config.HostConfig, netConfig, nil, config.Name)
-	return r, err
+// reattachAnonymousVolumes mounts the anonymous volumes of the given
+// container explicitly, so the container recreated from the configuration
+// gets the same volumes instead of new empty ones.
+// A volume is anonymous when neither the binds nor the mounts of the
+// container name it.
+func reattachAnonymousVolumes(config *container.InspectResponse) {
+	if config.ContainerJSONBase == nil || config.HostConfig == nil {
+		return
+	}
+	hc := config.HostConfig
+
+	named := make(map[string]struct{})
+	for _, bind := range hc.Binds {
+		if source, _, ok := strings.Cut(bind, ":"); ok {
+			named[source] = struct{}{}
+		}
+	}
+	for _, m := range hc.Mounts {
+		if m.Source != "" {
+			named[m.Source] = struct{}{}
+		}
+	}
+
+	for _, m := range config.Mounts {
+		if m.Type != mount.TypeVolume || m.Name == "" {
+			continue
+		}
+		if _, ok := named[m.Name]; ok {
+			continue
+		}
+
+		// an anonymous volume declared with --mount is in the mounts, without
+		// source.
+		declared := false
+		for i := range hc.Mounts {
+			if hc.Mounts[i].Target == m.Destination && hc.Mounts[i].Source == "" {
+				hc.Mounts[i].Source = m.Name
+				declared = true
+				break
+			}
+		}
+		if declared {
+			continue
+		}
+
+		bind := m.Name + ":" + m.Destination
+		if !m.RW {
+			bind += ":ro"
+		}
+		hc.Binds = append(hc.Binds, bind)
+	}
 }
 
 func sanitizeContainerJONVersion(containerJson *container.InspectResponse, apiVersionString string) {
@@ -0,0 +1,189 @@
+package client
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
+)
+
+const testContainerID = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+// inspected returns the inspected configuration of a container in the given
+// network mode, with the given endpoints.
+func inspected(mode string, networks map[string]*network.EndpointSettings) container.InspectResponse {
+	return container.InspectResponse{
+		ContainerJSONBase: &container.ContainerJSONBase{
+			ID:         testContainerID,
+			HostConfig: &container.HostConfig{NetworkMode: container.NetworkMode(mode)},
+		},
+		NetworkSettings: &container.NetworkSettings{Networks: networks},
+	}
+}
+
+// aliased returns an endpoint with the given aliases.
+func aliased(aliases ...string) *network.EndpointSettings {
+	return &network.EndpointSettings{Aliases: aliases}
+}
+
+func TestSplitNetworks(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      container.InspectResponse
+		wantPrimary map[string]*network.EndpointSettings
+		wantOthers  map[string]*network.EndpointSettings
+	}{
+		{
+			name:   "no network",
+			config: inspected("bridge", nil),
+		},
+		{
+			name:        "host mode kept as is",
+			config:      inspected("host", map[string]*network.EndpointSettings{"host": aliased("0123456789ab")}),
+			wantPrimary: map[string]*network.EndpointSettings{"host": aliased("0123456789ab")},
+		},
+		{
+			name:        "none mode kept as is",
+			config:      inspected("none", map[string]*network.EndpointSettings{"none": aliased()}),
+			wantPrimary: map[string]*network.EndpointSettings{"none": aliased()},
+		},
+		{
+			name:        "container mode kept as is",
+			config:      inspected("container:db", map[string]*network.EndpointSettings{"front": aliased("web")}),
+			wantPrimary: map[string]*network.EndpointSettings{"front": aliased("web")},
+		},
+		{
+			name: "network mode among the networks",
+			config: inspected("front", map[string]*network.EndpointSettings{
+				"back":  aliased("api"),
+				"front": aliased("web"),
+			}),
+			wantPrimary: map[string]*network.EndpointSettings{"front": aliased("web")},
+			wantOthers:  map[string]*network.EndpointSettings{"back": aliased("api")},
+		},
+		{
+			name: "network mode not among the networks",
+			config: inspected("default", map[string]*network.EndpointSettings{
+				"front": aliased("web"),
+				"back":  aliased("api"),
+			}),
+			wantPrimary: map[string]*network.EndpointSettings{"back": aliased("api")},
+			wantOthers:  map[string]*network.EndpointSettings{"front": aliased("web")},
+		},
+		{
+			name: "short ID alias removed",
+			config: inspected("front", map[string]*network.EndpointSettings{
+				"front": aliased("0123456789ab", "web"),
+				"back":  aliased("0123456789ab"),
+			}),
+			wantPrimary: map[string]*network.EndpointSettings{"front": aliased("web")},
+			wantOthers:  map[string]*network.EndpointSettings{"back": {}},
+		},
+		{
+			name: "user settings kept",
+			config: inspected("front", map[string]*network.EndpointSettings{
+				"front": {
+					IPAMConfig: &network.EndpointIPAMConfig{IPv4Address: "172.20.0.10"},
+					Links:      []string{"db:database"},
+					MacAddress: "02:42:ac:14:00:0a",
+					IPAddress:  "172.20.0.10",
+					NetworkID:  "f00",
+				},
+			}),
+			wantPrimary: map[string]*network.EndpointSettings{"front": {
+				IPAMConfig: &network.EndpointIPAMConfig{IPv4Address: "172.20.0.10"},
+				Links:      []string{"db:database"},
+				MacAddress: "02:42:ac:14:00:0a",
+			}},
+			wantOthers: map[string]*network.EndpointSettings{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			primary, others := splitNetworks(tt.config)
+			if !reflect.DeepEqual(primary, tt.wantPrimary) {
+				t.Errorf("primary = %s, want %s", endpointsString(primary), endpointsString(tt.wantPrimary))
+			}
+			if !reflect.DeepEqual(others, tt.wantOthers) {
+				t.Errorf("others = %s, want %s", endpointsString(others), endpointsString(tt.wantOthers))
+			}
+		})
+	}
+}
+
+// endpointsString returns the networks and aliases of the given endpoints,
+// for the test failures.
+func endpointsString(endpoints map[string]*network.EndpointSettings) string {
+	parts := make([]string, 0, len(endpoints))
+	for _, name := range sortedNetworks(endpoints) {
+		parts = append(parts, fmt.Sprintf("%s:%q", name, endpoints[name].Aliases))
+	}
+	return "{" + strings.Join(parts, " ") + "}"
+}
+
+func TestReattachAnonymousVolumes(t *testing.T) {
+	tests := []struct {
+		name       string
+		binds      []string
+		mounts     []mount.Mount
+		points     []container.MountPoint
+		wantBinds  []string
+		wantMounts []mount.Mount
+	}{
+		{
+			name:      "anonymous volume",
+			points:    []container.MountPoint{{Type: mount.TypeVolume, Name: "abc", Destination: "/data", RW: true}},
+			wantBinds: []string{"abc:/data"},
+		},
+		{
+			name:      "read-only anonymous volume",
+			points:    []container.MountPoint{{Type: mount.TypeVolume, Name: "abc", Destination: "/data"}},
+			wantBinds: []string{"abc:/data:ro"},
+		},
+		{
+			name:       "anonymous volume declared with --mount",
+			mounts:     []mount.Mount{{Type: mount.TypeVolume, Target: "/data"}},
+			points:     []container.MountPoint{{Type: mount.TypeVolume, Name: "abc", Destination: "/data", RW: true}},
+			wantMounts: []mount.Mount{{Type: mount.TypeVolume, Source: "abc", Target: "/data"}},
+		},
+		{
+			name:      "named volume bound",
+			binds:     []string{"db:/var/lib/db"},
+			points:    []container.MountPoint{{Type: mount.TypeVolume, Name: "db", Destination: "/var/lib/db", RW: true}},
+			wantBinds: []string{"db:/var/lib/db"},
+		},
+		{
+			name:       "named volume mounted",
+			mounts:     []mount.Mount{{Type: mount.TypeVolume, Source: "db", Target: "/var/lib/db"}},
+			points:     []container.MountPoint{{Type: mount.TypeVolume, Name: "db", Destination: "/var/lib/db", RW: true}},
+			wantMounts: []mount.Mount{{Type: mount.TypeVolume, Source: "db", Target: "/var/lib/db"}},
+		},
+		{
+			name:      "bind mount ignored",
+			binds:     []string{"/srv/web:/usr/share/nginx/html"},
+			points:    []container.MountPoint{{Type: mount.TypeBind, Source: "/srv/web", Destination: "/usr/share/nginx/html", RW: true}},
+			wantBinds: []string{"/srv/web:/usr/share/nginx/html"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := container.InspectResponse{
+				ContainerJSONBase: &container.ContainerJSONBase{
+					HostConfig: &container.HostConfig{Binds: tt.binds, Mounts: tt.mounts},
+				},
+				Mounts: tt.points,
+			}
+			reattachAnonymousVolumes(&config)
+			if got := config.HostConfig.Binds; !reflect.DeepEqual(got, tt.wantBinds) {
+				t.Errorf("binds = %q, want %q", got, tt.wantBinds)
+			}
+			if got := config.HostConfig.Mounts; !reflect.DeepEqual(got, tt.wantMounts) {
+				t.Errorf("mounts = %+v, want %+v", got, tt.wantMounts)
+			}
+		})
+	}
+}
@@ -0,0 +1,255 @@
+package client
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/client"
+)
+
+// DefaultContextName is the name of the implicit context built from the
+// environment (DOCKER_HOST, DOCKER_CERT_PATH, ...), as the docker CLI does.
+const DefaultContextName = "default"
+
+// ErrContextNotFound is returned when a named context does not exist in the
+// docker CLI contexts store.
+var ErrContextNotFound = errors.New("docker context not found")
+
+// Endpoint describes how to reach a Docker daemon.
+//
+// An Endpoint is either read from the docker CLI contexts store, built from
+// the --host flag or built from the environment.
+type Endpoint struct {
+	Name          string // Name is the context name, or the host when no context is used.
+	Description   string // Description is the context description, if any.
+	Host          string // Host is the daemon URL (unix://, tcp://, ssh://, npipe://).
+	TLSVerify     bool   // TLSVerify requires TLS and the server certificate verification, against the system roots without CAFile.
+	SkipTLSVerify bool   // SkipTLSVerify disables the server certificate verification.
+	CAFile        string // CAFile is the path of the CA certificate, if any.
+	CertFile      string // CertFile is the path of the client certificate, if any.
+	KeyFile       string // KeyFile is the path of the client key, if any.
+}
+
+// TLS reports whether the endpoint is reached over TLS: when it requires
+// the verification of the server or carries TLS material.
+func (e Endpoint) TLS() bool {
+	return e.TLSVerify || e.CAFile != "" || e.CertFile != "" || e.KeyFile != ""
+}
+
+// Local reports whether the daemon of the endpoint runs on this machine,
+// i.e. is reached through a unix socket or a named pipe.
+func (e Endpoint) Local() bool {
+	return strings.HasPrefix(e.Host, "unix://") || strings.HasPrefix(e.Host, "npipe://")
+}
+
+// CLIArgs returns the docker CLI global flags selecting the endpoint, so
+// docker commands run by gmd (logs, exec, ...) reach the same daemon.
+func (e Endpoint) CLIArgs() []string {
+	switch {
+	case e.Name == DefaultContextName && (os.Getenv(client.EnvOverrideHost) != "" || e.Host == client.DefaultDockerHost):
+		return nil
+	case e.Name == DefaultContextName:
+		// the socket was detected by gmd, the docker CLI would not find it.
+		return []string{"--host", e.Host}
+	case e.Name != e.Host:
+		return []string{"--context", e.Name}
+	}
+
+	args := []string{"--host", e.Host}
+	if e.TLS() {
+		if e.TLSVerify || !e.SkipTLSVerify {
+			args = append(args, "--tlsverify")
+		}
+		if e.CAFile != "" {
+			args = append(args, "--tlscacert", e.CAFile)
+		}
+		if e.CertFile != "" {
+			args = append(args, "--tlscert", e.CertFile)
+		}
+		if e.KeyFile != "" {
+			args = append(args, "--tlskey", e.KeyFile)
+		}
+	}
+	return args
+}
+
+// contextMeta is the content of a meta.json file in the contexts store.
+type contextMeta struct {
+	Name     string `json:"Name"`
+	Metadata struct {
+		Description string `json:"Description"`
+	} `json:"Metadata"`
+	Endpoints map[string]struct {
+		Host          string `json:"Host"`
+		SkipTLSVerify bool   `json:"SkipTLSVerify"`
+	} `json:"Endpoints"`
+}
+
+// dockerConfigDir returns the docker CLI configuration directory,
+// honoring DOCKER_CONFIG.
+func dockerConfigDir() string {
+	if dir := os.Getenv("DOCKER_CONFIG"); dir != "" {
+		return dir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ".docker"
+	}
+	return filepath.Join(home, ".docker")
+}
+
+// contextDirName returns the directory name used by the contexts store for
+// the given context name.
+func contextDirName(name string) string {
+	sum := sha256.Sum256([]byte(name))
+	return hex.EncodeToString(sum[:])
+}
+
+// currentContext returns the context selected by DOCKER_CONTEXT or by the
+// currentContext field of the docker CLI config.json.
+func currentContext() string {
+	if name := os.Getenv("DOCKER_CONTEXT"); name != "" {
+		return name
+	}
+	data, err := os.ReadFile(filepath.Join(dockerConfigDir(), "config.json"))
+	if err != nil {
+		return DefaultContextName
+	}
+	var conf struct {
+		CurrentContext string `json:"currentContext"`
+	}
+	if err := json.Unmarshal(data, &conf); err != nil || conf.CurrentContext == "" {
+		return DefaultContextName
+	}
+	return conf.CurrentContext
+}
+
+// defaultEndpoint returns the endpoint built from the environment, the same
+// way the docker CLI builds its "default" context. Without DOCKER_HOST, the
+// local rootful and rootless Docker and Podman sockets are looked for.
+func defaultEndpoint() Endpoint {
+	e := Endpoint{
+		Name:        DefaultContextName,
+		Description: "Current DOCKER_HOST based configuration",
+		Host:        os.Getenv(client.EnvOverrideHost),
+	}
+	if e.Host == "" {
+		e.Host = detectLocalHost()
+	}
+	e.TLSVerify = os.Getenv(client.EnvTLSVerify) != ""
+	certPath := os.Getenv(client.EnvOverrideCertPath)
+	if certPath == "" && e.TLSVerify {
+		// the docker CLI defaults to its configuration directory.
+		certPath = dockerConfigDir()
+	}
+	if certPath != "" {
+		e.CAFile = existingFile(filepath.Join(certPath, "ca.pem"))
+		e.CertFile = existingFile(filepath.Join(certPath, "cert.pem"))
+		e.KeyFile = existingFile(filepath.Join(certPath, "key.pem"))
+		e.SkipTLSVerify = !e.TLSVerify
+	}
+	return e
+}
+
+// LoadContext reads the named context from the docker CLI contexts store.
+// The "default" context is built from the environment.
+// ErrContextNotFound is returned if the context does not exist.
+func LoadContext(name string) (Endpoint, error) {
+	if name == DefaultContextName {
+		return defaultEndpoint(), nil
+	}
+
+	storeDir := filepath.Join(dockerConfigDir(), "contexts")
+	dirName := contextDirName(name)
+
+	data, err := os.ReadFile(filepath.Join(storeDir, "meta", dirName, "meta.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return Endpoint{}, fmt.Errorf("%w: %s", ErrContextNotFound, name)
+	}
+	if err != nil {
+		return Endpoint{}, err
+	}
+
+	var meta contextMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return Endpoint{}, fmt.Errorf("context %s: %w", name, err)
+	}
+
+	docker, ok := meta.Endpoints["docker"]
+	if !ok || docker.Host == "" {
+		return Endpoint{}, fmt.Errorf("context %s has no docker endpoint", name)
+	}
+
+	e := Endpoint{
+		Name:          meta.Name,
+		Description:   meta.Metadata.Description,
+		Host:          docker.Host,
+		SkipTLSVerify: docker.SkipTLSVerify,
+	}
+
+	// the store has a TLS directory for the endpoints configured with TLS,
+	// even when they carry no certificate.
+	tlsDir := filepath.Join(storeDir, "tls", dirName, "docker")
+	if info, err := os.Stat(tlsDir); err == nil && info.IsDir() {
+		e.TLSVerify = !docker.SkipTLSVerify
+		e.CAFile = existingFile(filepath.Join(tlsDir, "ca.pem"))
+		e.CertFile = existingFile(filepath.Join(tlsDir, "cert.pem"))
+		e.KeyFile = existingFile(filepath.Join(tlsDir, "key.pem"))
+	}
+
+	return e, nil
+}
+
+// existingFile returns the given path if the file exists, or an empty
+// string otherwise.
+func existingFile(path string) string {
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+	return path
+}
+
+// ListContexts returns every context of the docker CLI contexts store,
+// sorted by name, with the "default" context first.
+func ListContexts() ([]Endpoint, error) {
+	out := []Endpoint{defaultEndpoint()}
+
+	metaDir := filepath.Join(dockerConfigDir(), "contexts", "meta")
+	entries, err := os.ReadDir(metaDir)
+	if errors.Is(err, os.ErrNotExist) {
+		return out, nil
+	}
+	if err != nil {
+		return out, err
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		data, err := os.ReadFile(filepath.Join(metaDir, entry.Name(), "meta.json"))
+		if err != nil {
+			continue
+		}
+		var meta contextMeta
+		if err := json.Unmarshal(data, &meta); err != nil || meta.Name == "" {
+			continue
+		}
+		names = append(names, meta.Name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		e, err := LoadContext(name)
+		if err != nil {
+			continue
+		}
+		out = append(out, e)
+	}
+	return out, nil
+}
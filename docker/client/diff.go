@@ -0,0 +1,91 @@
+package client
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+// ConfigChange is a field of the create request of a container whose value
+// differs between two configurations.
+type ConfigChange struct {
+	Path string // Path is the dotted path of the field, e.g. HostConfig.PortBindings.80/tcp.
+	Old  string // Old is the JSON value of the field in the first configuration, empty if unset.
+	New  string // New is the JSON value of the field in the second configuration, empty if unset.
+}
+
+// DiffConfig returns the fields of the create request of a container that
+// differ between the given configurations, typically the inspect of a
+// container and the configuration returned by CreateConfig for it.
+// The compared fields are the name, Config, HostConfig and the network
+// endpoints of the container. Changes are sorted by path.
+func DiffConfig(current, create container.InspectResponse) []ConfigChange {
+	before := flattenConfig(current)
+	after := flattenConfig(create)
+
+	var changes []ConfigChange
+	for path, o := range before {
+		if n := after[path]; n != o {
+			changes = append(changes, ConfigChange{Path: path, Old: o, New: n})
+		}
+	}
+	for path, n := range after {
+		if _, ok := before[path]; !ok {
+			changes = append(changes, ConfigChange{Path: path, New: n})
+		}
+	}
+
+	slices.SortFunc(changes, func(a, b ConfigChange) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	return changes
+}
+
+// flattenConfig returns the fields of the create request of the given
+// configuration by dotted path, with their JSON values.
+func flattenConfig(config container.InspectResponse) map[string]string {
+	request := map[string]any{
+		"Config":   config.Config,
+		"Networks": nil,
+	}
+	if config.ContainerJSONBase != nil {
+		request["Name"] = config.Name
+		request["HostConfig"] = config.HostConfig
+	}
+	if config.NetworkSettings != nil {
+		request["Networks"] = config.NetworkSettings.Networks
+	}
+
+	// go through JSON, so the fields are named like in the API.
+	raw, err := json.Marshal(request)
+	if err != nil {
+		return nil
+	}
+	var tree any
+	if err := json.Unmarshal(raw, &tree); err != nil {
+		return nil
+	}
+
+	out := make(map[string]string)
+	flatten("", tree, out)
+	return out
+}
+
+// flatten adds the leaves of the given JSON tree to out, by dotted path.
+// Objects are walked, any other value, arrays included, is a leaf.
+func flatten(path string, tree any, out map[string]string) {
+	if obj, ok := tree.(map[string]any); ok && len(obj) > 0 {
+		for k, v := range obj {
+			p := k
+			if path != "" {
+				p = path + "." + k
+			}
+			flatten(p, v, out)
+		}
+		return
+	}
+	raw, _ := json.Marshal(tree)
+	out[path] = string(raw)
+}
@@ -0,0 +1,123 @@
+package client
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/go-connections/nat"
+)
+
+// diffed returns the configuration of a web container, changed by the
+// given function.
+func diffed(change func(c *container.InspectResponse)) container.InspectResponse {
+	c := container.InspectResponse{
+		ContainerJSONBase: &container.ContainerJSONBase{
+			Name: "/web",
+			HostConfig: &container.HostConfig{
+				Binds: []string{"data:/data"},
+				PortBindings: nat.PortMap{
+					"80/tcp": {{HostPort: "8080"}},
+				},
+			},
+		},
+		Config: &container.Config{
+			Image: "nginx:1.27",
+			Env:   []string{"A=1"},
+		},
+		NetworkSettings: &container.NetworkSettings{
+			Networks: map[string]*network.EndpointSettings{
+				"front": {Aliases: []string{"web"}},
+			},
+		},
+	}
+	if change != nil {
+		change(&c)
+	}
+	return c
+}
+
+func TestDiffConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(c *container.InspectResponse)
+		want   []ConfigChange
+	}{
+		{
+			name: "equal",
+		},
+		{
+			name:   "image",
+			change: func(c *container.InspectResponse) { c.Config.Image = "nginx:1.28" },
+			want:   []ConfigChange{{Path: "Config.Image", Old: `"nginx:1.27"`, New: `"nginx:1.28"`}},
+		},
+		{
+			name:   "environment",
+			change: func(c *container.InspectResponse) { c.Config.Env = append(c.Config.Env, "B=2") },
+			want:   []ConfigChange{{Path: "Config.Env", Old: `["A=1"]`, New: `["A=1","B=2"]`}},
+		},
+		{
+			name: "ports",
+			change: func(c *container.InspectResponse) {
+				c.HostConfig.PortBindings = nat.PortMap{
+					"80/tcp":  {{HostPort: "8081"}},
+					"443/tcp": {{HostPort: "8443"}},
+				}
+			},
+			want: []ConfigChange{
+				{Path: "HostConfig.PortBindings.443/tcp", New: `[{"HostIp":"","HostPort":"8443"}]`},
+				{Path: "HostConfig.PortBindings.80/tcp", Old: `[{"HostIp":"","HostPort":"8080"}]`, New: `[{"HostIp":"","HostPort":"8081"}]`},
+			},
+		},
+		{
+			name:   "mounts",
+			change: func(c *container.InspectResponse) { c.HostConfig.Binds = []string{"data:/data:ro"} },
+			want:   []ConfigChange{{Path: "HostConfig.Binds", Old: `["data:/data"]`, New: `["data:/data:ro"]`}},
+		},
+		{
+			name: "network aliases",
+			change: func(c *container.InspectResponse) {
+				c.NetworkSettings.Networks["front"].Aliases = []string{"web", "www"}
+			},
+			want: []ConfigChange{{Path: "Networks.front.Aliases", Old: `["web"]`, New: `["web","www"]`}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DiffConfig(diffed(nil), diffed(tt.change))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffConfig = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffConfigNetworks(t *testing.T) {
+	got := DiffConfig(diffed(nil), diffed(func(c *container.InspectResponse) {
+		delete(c.NetworkSettings.Networks, "front")
+		c.NetworkSettings.Networks["back"] = &network.EndpointSettings{Aliases: []string{"web"}}
+	}))
+
+	var added, removed bool
+	for _, change := range got {
+		switch {
+		case strings.HasPrefix(change.Path, "Networks.back."):
+			added = true
+			if change.Old != "" {
+				t.Errorf("%s: old = %s, want unset", change.Path, change.Old)
+			}
+		case strings.HasPrefix(change.Path, "Networks.front."):
+			removed = true
+			if change.New != "" {
+				t.Errorf("%s: new = %s, want unset", change.Path, change.New)
+			}
+		default:
+			t.Errorf("unexpected change of %s", change.Path)
+		}
+	}
+	if !added || !removed {
+		t.Errorf("DiffConfig = %+v, want the back network added and the front one removed", got)
+	}
+}
@@ -7,12 +7,17 @@ import (
 	"github.com/docker/docker/api/types/filters"
 )
 
-func (c *Client) StartEvents() (<-chan events.Message, <-chan error) {
+// StartEvents subscribes to the events of the daemon, replacing the previous
+// subscription if any.
+// The subscription lasts until the given context is canceled or StopEvents is called.
+// StartEvents and StopEvents can be called concurrently.
+func (c *Client) StartEvents(ctx context.Context) (<-chan events.Message, <-chan error) {
 
 	filters := filters.NewArgs()
 	filters.Add("type", string(events.ContainerEventType))
 	filters.Add("type", string(events.ImageEventType))
 	filters.Add("type", string(events.VolumeEventType))
+	filters.Add("type", string(events.NetworkEventType))
 
 	filters.Add("event", string(events.ActionCreate))
 	filters.Add("event", string(events.ActionStart))
@@ -25,15 +30,28 @@ func (c *Client) StartEvents() (<-chan events.Message, <-chan error) {
 	filters.Add("event", string(events.ActionUnPause))
 	filters.Add("event", string(events.ActionRename))
 	filters.Add("event", string(events.ActionDestroy))
+	filters.Add("event", string(events.ActionConnect))
+	filters.Add("event", string(events.ActionDisconnect))
 
 	filters.Add("event", string(events.ActionPush))
 	filters.Add("event", string(events.ActionPull))
 	filters.Add("event", string(events.ActionPrune))
 	filters.Add("event", string(events.ActionDelete))
+	filters.Add("event", string(events.ActionTag))
+	filters.Add("event", string(events.ActionUnTag))
+	filters.Add("event", string(events.ActionLoad))
+	filters.Add("event", string(events.ActionImport))
 
-	c.eventsContext, c.eventsCancel = context.WithCancel(context.Background())
+	eventsContext, eventsCancel := context.WithCancel(ctx)
 
-	ev, errors := c.cli.Events(c.eventsContext, events.ListOptions{
+	c.eventsMu.Lock()
+	if c.eventsCancel != nil {
+		c.eventsCancel()
+	}
+	c.eventsContext, c.eventsCancel = eventsContext, eventsCancel
+	c.eventsMu.Unlock()
+
+	ev, errors := c.cli.Events(eventsContext, events.ListOptions{
 		Filters: filters,
 	})
 
@@ -41,7 +59,13 @@ func (c *Client) StartEvents() (<-chan events.Message, <-chan error) {
 
 }
 
+// StopEvents cancels the subscription started by StartEvents.
 func (c *Client) StopEvents() {
+	c.eventsMu.Lock()
+	defer c.eventsMu.Unlock()
+	if c.eventsCancel == nil {
+		return
+	}
 	c.eventsCancel()
 	<-c.eventsContext.Done()
 }
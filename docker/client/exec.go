@@ -0,0 +1,47 @@
+package client
+
+import (
+	"context"
+	"io"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+// Exec runs the given command inside the container with the given ID and
+// writes its standard and error outputs to output, until it exits.
+// It returns the exit code of the command, and an error if the command
+// could not be run.
+func (c *Client) Exec(ctx context.Context, id string, cmd []string, output io.Writer) (int, error) {
+	exec, err := c.cli.ContainerExecCreate(ctx, id, container.ExecOptions{
+		Cmd:          cmd,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return -1, err
+	}
+
+	resp, err := c.cli.ContainerExecAttach(ctx, exec.ID, container.ExecAttachOptions{})
+	if err != nil {
+		return -1, err
+	}
+	defer resp.Close()
+
+	// closing the connection unblocks the copy when ctx is canceled.
+	stop := context.AfterFunc(ctx, resp.Close)
+	defer stop()
+
+	if _, err := stdcopy.StdCopy(output, output, resp.Reader); err != nil && ctx.Err() == nil {
+		return -1, err
+	}
+	if err := ctx.Err(); err != nil {
+		return -1, err
+	}
+
+	inspect, err := c.cli.ContainerExecInspect(ctx, exec.ID)
+	if err != nil {
+		return -1, err
+	}
+	return inspect.ExitCode, nil
+}
@@ -0,0 +1,92 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+// healthPollInterval is the interval between two inspects of a container
+// waited for by WaitHealthy.
+const healthPollInterval = time.Second
+
+// ErrUnhealthy is returned by WaitHealthy when the container did not start
+// successfully.
+var ErrUnhealthy = errors.New("container is unhealthy")
+
+// WaitHealthy waits for the container with the given ID to run successfully
+// after it was started, for at most the Health timeout of the client.
+//
+// A container whose image defines a health check must reach the healthy
+// state. A container without health check succeeds once it runs, or once it
+// kept running for the Stable duration of the client, bounded by the
+// timeout, if it is set. In both cases, the
+// container fails if it restarts or exits with a non-zero code, and
+// succeeds if it exits cleanly.
+// A zero Health timeout skips the wait.
+// It returns an error wrapping ErrUnhealthy if the container failed.
+func (c *Client) WaitHealthy(ctx context.Context, id string) error {
+	timeout := c.timeouts.Health
+	if timeout <= 0 {
+		return nil
+	}
+	stable := min(c.timeouts.Stable, timeout)
+	start := time.Now()
+
+	ticker := time.NewTicker(healthPollInterval)
+	defer ticker.Stop()
+
+	for {
+		inspect, err := c.ContainerInspect(ctx, id)
+		if err != nil {
+			return err
+		}
+		state := inspect.State
+		if state == nil {
+			return fmt.Errorf("%w: no state reported", ErrUnhealthy)
+		}
+
+		switch {
+		case inspect.RestartCount > 0:
+			return fmt.Errorf("%w: restarted %d times, last exit code %d", ErrUnhealthy, inspect.RestartCount, state.ExitCode)
+		case !state.Running && state.ExitCode != 0:
+			return fmt.Errorf("%w: exited with code %d", ErrUnhealthy, state.ExitCode)
+		case !state.Running && state.Status == container.StateExited:
+			// the container did its job and exited cleanly.
+			return nil
+		case !state.Running && state.Status == container.StateDead:
+			return fmt.Errorf("%w: %s", ErrUnhealthy, state.Status)
+		case state.Health != nil && state.Health.Status == container.Healthy:
+			return nil
+		case state.Health != nil && state.Health.Status == container.Unhealthy:
+			return fmt.Errorf("%w: %s", ErrUnhealthy, lastHealthOutput(state.Health))
+		case state.Health == nil && state.Running && time.Since(start) >= stable:
+			return nil
+		case time.Since(start) >= timeout:
+			return fmt.Errorf("%w: not healthy after %s", ErrUnhealthy, timeout)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// lastHealthOutput returns the output of the last health check of a
+// container, or its health status if it has none.
+func lastHealthOutput(health *container.Health) string {
+	if len(health.Log) == 0 {
+		return health.Status
+	}
+	out := strings.TrimSpace(health.Log[len(health.Log)-1].Output)
+	if out == "" {
+		return health.Status
+	}
+	return out
+}
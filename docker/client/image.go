@@ -11,6 +11,8 @@ import (
 // It does not force the deletion of the image, and it does prune children.
 // The function returns an error if the deletion fails.
 func (c *Client) DeleteImage(ctx context.Context, imageID string) error {
+	ctx, cancel := withTimeout(ctx, c.timeouts.Default)
+	defer cancel()
 	_, err := c.cli.ImageRemove(ctx, imageID, image.RemoveOptions{
 		Force:         false,
 		PruneChildren: true,
@@ -18,16 +20,39 @@ func (c *Client) DeleteImage(ctx context.Context, imageID string) error {
 	return err
 }
 
+// TagImage tags the image with the given ID or reference as target,
+// replacing the image target referred to.
+// It returns an error if the image could not be tagged.
+func (c *Client) TagImage(ctx context.Context, source, target string) error {
+	ctx, cancel := withTimeout(ctx, c.timeouts.Default)
+	defer cancel()
+	return c.cli.ImageTag(ctx, source, target)
+}
+
+// UntagImage removes the given tag. The image it refers to is deleted if no
+// other tag nor container refers to it.
+// It returns an error if the tag could not be removed.
+func (c *Client) UntagImage(ctx context.Context, ref string) error {
+	ctx, cancel := withTimeout(ctx, c.timeouts.Default)
+	defer cancel()
+	_, err := c.cli.ImageRemove(ctx, ref, image.RemoveOptions{})
+	return err
+}
+
 // PullImageWithProgress pulls an image from the Docker Hub and prints
 // the progress of the pull to the given function.
 // The function returns an error if the pull fails.
 func (c *Client) PullImageWithProgress(ctx context.Context, imageRef string, progress func(map[string]interface{})) (err error) {
+	ctx, cancel := withTimeout(ctx, c.timeouts.Pull)
+	defer cancel()
 	reader, err := c.cli.ImagePull(ctx, imageRef, image.PullOptions{})
 	if err != nil {
 		return err
 	}
 	defer func() {
-		err = reader.Close()
+		if cerr := reader.Close(); err == nil {
+			err = cerr
+		}
 	}()
 	decoder := json.NewDecoder(reader)
 
@@ -46,8 +71,18 @@ func (c *Client) PullImageWithProgress(ctx context.Context, imageRef string, pro
 // The function returns an error if the list of images cannot be retrieved.
 // The list of images includes all images on the daemon, including intermediate images.
 // The list of images is sorted by image name.
-func (c *Client) ImageList() ([]image.Summary, error) {
-	return c.cli.ImageList(context.Background(), image.ListOptions{All: true})
+func (c *Client) ImageList(ctx context.Context) ([]image.Summary, error) {
+	ctx, cancel := withTimeout(ctx, c.timeouts.Default)
+	defer cancel()
+	return c.cli.ImageList(ctx, image.ListOptions{All: true})
+}
+
+// ImageInspect returns the details of the image with the given ID or reference.
+// The function returns an error if the image cannot be inspected.
+func (c *Client) ImageInspect(ctx context.Context, imageID string) (image.InspectResponse, error) {
+	ctx, cancel := withTimeout(ctx, c.timeouts.Default)
+	defer cancel()
+	return c.cli.ImageInspect(ctx, imageID)
 }
 
 // ImageHistory returns the history of an image on the Docker daemon.
@@ -56,6 +91,8 @@ func (c *Client) ImageList() ([]image.Summary, error) {
 // creation time.
 // The function returns an error if the history of the image cannot be
 // retrieved.
-func (c *Client) ImageHistory(imageID string) ([]image.HistoryResponseItem, error) {
-	return c.cli.ImageHistory(context.Background(), imageID)
+func (c *Client) ImageHistory(ctx context.Context, imageID string) ([]image.HistoryResponseItem, error) {
+	ctx, cancel := withTimeout(ctx, c.timeouts.Default)
+	defer cancel()
+	return c.cli.ImageHistory(ctx, imageID)
 }
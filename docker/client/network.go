@@ -0,0 +1,66 @@
+package client
+
+import (
+	"context"
+
+	"github.com/docker/docker/api/types/network"
+)
+
+// NetworkList returns the networks of the Docker daemon.
+// The containers attached to the networks are not listed, see NetworkInspect.
+// The function returns an error if the list of networks cannot be retrieved.
+func (c *Client) NetworkList(ctx context.Context) ([]network.Summary, error) {
+	ctx, cancel := withTimeout(ctx, c.timeouts.Default)
+	defer cancel()
+	return c.cli.NetworkList(ctx, network.ListOptions{})
+}
+
+// NetworkInspect returns the details of the network with the given ID,
+// including the containers attached to it.
+// The function returns an error if the network cannot be inspected.
+func (c *Client) NetworkInspect(ctx context.Context, id string) (network.Inspect, error) {
+	ctx, cancel := withTimeout(ctx, c.timeouts.Default)
+	defer cancel()
+	return c.cli.NetworkInspect(ctx, id, network.InspectOptions{})
+}
+
+// CreateNetwork creates a network with the given name and driver.
+// An empty driver selects the default "bridge" driver.
+// The function returns the ID of the created network, or an error if the
+// creation fails.
+func (c *Client) CreateNetwork(ctx context.Context, name, driver string) (string, error) {
+	ctx, cancel := withTimeout(ctx, c.timeouts.Default)
+	defer cancel()
+	resp, err := c.cli.NetworkCreate(ctx, name, network.CreateOptions{Driver: driver})
+	if err != nil {
+		return "", err
+	}
+	return resp.ID, nil
+}
+
+// DeleteNetwork deletes the network with the given ID.
+// The function returns an error if the deletion fails, e.g. when containers
+// are still attached to the network.
+func (c *Client) DeleteNetwork(ctx context.Context, id string) error {
+	ctx, cancel := withTimeout(ctx, c.timeouts.Default)
+	defer cancel()
+	return c.cli.NetworkRemove(ctx, id)
+}
+
+// ConnectNetwork attaches a container to a network with the given endpoint
+// settings, which carry the aliases and static IPs of the container on the
+// network. Nil settings let the daemon pick them.
+// The function returns an error if the container cannot be attached.
+func (c *Client) ConnectNetwork(ctx context.Context, networkID, containerID string, settings *network.EndpointSettings) error {
+	ctx, cancel := withTimeout(ctx, c.timeouts.Default)
+	defer cancel()
+	return c.cli.NetworkConnect(ctx, networkID, containerID, settings)
+}
+
+// DisconnectNetwork detaches a container from a network.
+// The function returns an error if the container cannot be detached.
+func (c *Client) DisconnectNetwork(ctx context.Context, networkID, containerID string) error {
+	ctx, cancel := withTimeout(ctx, c.timeouts.Default)
+	defer cancel()
+	return c.cli.NetworkDisconnect(ctx, networkID, containerID, false)
+}
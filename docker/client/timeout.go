@@ -0,0 +1,41 @@
+package client
+
+import (
+	"context"
+	"time"
+)
+
+// Timeouts holds the maximum duration of each kind of operation.
+// A zero duration disables the timeout of that kind of operation, the
+// caller context is then the only way to cancel it.
+type Timeouts struct {
+	Default  time.Duration // Default bounds quick calls: list, inspect, start, create, remove.
+	Stop     time.Duration // Stop bounds stop and restart calls, which wait for the container grace period.
+	Pull     time.Duration // Pull bounds image pulls.
+	Registry time.Duration // Registry bounds registry lookups done to check for updates.
+	Health   time.Duration // Health bounds the wait for an updated container to be healthy. Zero skips the wait.
+	Stable   time.Duration // Stable is how long an updated container without health check must keep running. Zero only waits for it to run.
+}
+
+// DefaultTimeouts are the timeouts used when none are configured.
+var DefaultTimeouts = Timeouts{
+	Default:  30 * time.Second,
+	Stop:     2 * time.Minute,
+	Pull:     30 * time.Minute,
+	Registry: 30 * time.Second,
+	Health:   2 * time.Minute,
+}
+
+// withTimeout returns a copy of ctx bounded by the given duration.
+// A zero duration returns a copy of ctx that is only canceled with ctx.
+func withTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, d)
+}
+
+// Timeouts returns the timeouts used by the client.
+func (c *Client) Timeouts() Timeouts {
+	return c.timeouts
+}
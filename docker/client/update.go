@@ -7,28 +7,49 @@ import (
 	"runtime"
 	"slices"
 	"strings"
+	"time"
 
 	"github.com/docker/docker/api/types/image"
 	"github.com/google/go-containerregistry/pkg/name"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/kdruelle/gmd/docker/types"
 )
 
 // CheckUpdate checks if the given container needs to be updated.
 // It returns true if an update is needed, false otherwise.
 // It also returns an error if an error occurs during the check.
-func (c *Client) CheckUpdate(containerID string) (bool, error) {
+//
+// The container is compared to the image it is updated to, see
+// types.Container.UpdateImage. A container whose update policy is
+// types.UpdatePolicySkip never needs an update, the registry is not queried.
+func (c *Client) CheckUpdate(ctx context.Context, containerID string) (bool, error) {
+	digest, err := c.UpdateDigest(ctx, containerID)
+	return digest != "", err
+}
+
+// UpdateDigest checks if the given container needs to be updated, like
+// CheckUpdate.
+// It returns the registry digest of the image the container would be updated
+// to if an update is needed, an empty string otherwise.
+func (c *Client) UpdateDigest(ctx context.Context, containerID string) (string, error) {
 
-	container, err := c.ContainerInspect(containerID)
+	container, err := c.ContainerInspect(ctx, containerID)
 	if err != nil {
-		return false, err
+		return "", err
 	}
 
+	t := types.Container{InspectResponse: container}
+	if t.UpdatePolicy() == types.UpdatePolicySkip {
+		return "", nil
+	}
+	ref := t.UpdateImage()
+
 	var image image.Summary
-	images, err := c.ImageList()
+	images, err := c.ImageList(ctx)
 
 	if err != nil {
-		return false, err
+		return "", err
 	}
 
 	for _, img := range images {
@@ -39,7 +60,7 @@ func (c *Client) CheckUpdate(containerID string) (bool, error) {
 	}
 
 	if image.ID == "" {
-		return false, fmt.Errorf("image %s not found", container.Image)
+		return "", fmt.Errorf("image %s not found", container.Image)
 	}
 	// log.Printf("check update for container %s:  image %s - %s - %+v", c.Name, c.Image, c.Config.Image)
 
@@ -51,15 +72,21 @@ func (c *Client) CheckUpdate(containerID string) (bool, error) {
 	// 	return true, nil
 	// }
 
-	localDigests, err := c.getLocalDigests(container.Config.Image)
+	// a tracked tag may not be pulled yet, compare with the image the
+	// container runs.
+	local := container.Config.Image
+	if ref != container.Config.Image {
+		local = container.Image
+	}
+	localDigests, err := c.getLocalDigests(ctx, local)
 	if err != nil {
-		return false, err
+		return "", err
 	}
 
-	remoteDigest, err := getRemoteDigest(container.Config.Image)
+	remoteDigest, err := c.getRemoteDigest(ctx, ref)
 	if err != nil {
 		log.Printf("image : %s, localDigests: %v, err: %s", container.Image, localDigests, err)
-		return false, err
+		return "", err
 	}
 
 	log.Printf("image : %s, localDigests: %v, remoteDigest: %s", container.Image, localDigests, remoteDigest)
@@ -69,17 +96,19 @@ func (c *Client) CheckUpdate(containerID string) (bool, error) {
 	}
 
 	if slices.ContainsFunc(localDigests, f) {
-		return false, nil
+		return "", nil
 	}
 
 	log.Printf("image to update : %s, container: %s, localDigests: %v, remoteDigest: %s", image.ID, container.ID, localDigests, remoteDigest)
 
-	return true, nil
+	return remoteDigest, nil
 }
 
-func (c *Client) getLocalDigests(imageID string) ([]string, error) {
+func (c *Client) getLocalDigests(ctx context.Context, imageID string) ([]string, error) {
+	ctx, cancel := withTimeout(ctx, c.timeouts.Default)
+	defer cancel()
 	//imgInspect, _, err := cli.ImageInspectWithRaw(ctx, imageID)
-	imgInspect, err := c.cli.ImageInspect(context.Background(), imageID)
+	imgInspect, err := c.cli.ImageInspect(ctx, imageID)
 	if err != nil {
 		return nil, err
 	}
@@ -90,7 +119,9 @@ func (c *Client) getLocalDigests(imageID string) ([]string, error) {
 	return imgInspect.RepoDigests, nil
 }
 
-func getRemoteDigest(image string) (string, error) {
+func (c *Client) getRemoteDigest(ctx context.Context, image string) (string, error) {
+	ctx, cancel := withTimeout(ctx, c.timeouts.Registry)
+	defer cancel()
 
 	log.Printf("getRemoteDigest for %s", image)
 
@@ -101,6 +132,7 @@ func getRemoteDigest(image string) (string, error) {
 
 	// HEAD request for manifest digest
 	desc, err := remote.Head(ref,
+		remote.WithContext(ctx),
 		remote.WithPlatform(v1.Platform{Architecture: runtime.GOARCH, OS: runtime.GOOS}),
 	)
 	if err != nil {
@@ -109,3 +141,82 @@ func getRemoteDigest(image string) (string, error) {
 
 	return desc.Digest.String(), nil
 }
+
+// ImageInfo identifies a version of an image.
+type ImageInfo struct {
+	Ref     string    // Ref is the reference the image was looked up with.
+	ID      string    // ID is the ID of the image, only known for local images.
+	Digest  string    // Digest is the repository digest of the image.
+	Created time.Time // Created is the creation date of the image.
+}
+
+// LocalImage returns the local image with the given ID, whose digest is the
+// repository digest matching the given reference, if any.
+// It returns an error if the image could not be inspected.
+func (c *Client) LocalImage(ctx context.Context, id, ref string) (ImageInfo, error) {
+	inspect, err := c.ImageInspect(ctx, id)
+	if err != nil {
+		return ImageInfo{}, err
+	}
+
+	info := ImageInfo{Ref: ref, ID: inspect.ID}
+	if created, err := time.Parse(time.RFC3339Nano, inspect.Created); err == nil {
+		info.Created = created
+	}
+
+	repo := ref
+	if named, err := name.ParseReference(ref); err == nil {
+		repo = named.Context().Name()
+	}
+	for _, d := range inspect.RepoDigests {
+		r, digest, ok := strings.Cut(d, "@")
+		if !ok {
+			continue
+		}
+		if named, err := name.ParseReference(d); err == nil {
+			r = named.Context().Name()
+		}
+		if r == repo {
+			info.Digest = digest
+			break
+		}
+	}
+	if info.Digest == "" && len(inspect.RepoDigests) > 0 {
+		_, info.Digest, _ = strings.Cut(inspect.RepoDigests[0], "@")
+	}
+
+	return info, nil
+}
+
+// RemoteImage returns the image the given reference points to in its
+// registry, for the platform of gmd.
+// It returns an error if the registry could not be reached.
+func (c *Client) RemoteImage(ctx context.Context, ref string) (ImageInfo, error) {
+	digest, err := c.getRemoteDigest(ctx, ref)
+	if err != nil {
+		return ImageInfo{}, err
+	}
+	info := ImageInfo{Ref: ref, Digest: digest}
+
+	ctx, cancel := withTimeout(ctx, c.timeouts.Registry)
+	defer cancel()
+
+	named, err := name.ParseReference(ref)
+	if err != nil {
+		return ImageInfo{}, err
+	}
+	img, err := remote.Image(named,
+		remote.WithContext(ctx),
+		remote.WithPlatform(v1.Platform{Architecture: runtime.GOARCH, OS: runtime.GOOS}),
+	)
+	if err != nil {
+		return ImageInfo{}, err
+	}
+	config, err := img.ConfigFile()
+	if err != nil {
+		return ImageInfo{}, err
+	}
+	info.Created = config.Created.Time
+
+	return info, nil
+}
@@ -0,0 +1,69 @@
+package client
+
+import (
+	"context"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/volume"
+)
+
+// VolumeList returns the volumes of the Docker daemon.
+// The function returns an error if the list of volumes cannot be retrieved.
+func (c *Client) VolumeList(ctx context.Context) ([]*volume.Volume, error) {
+	ctx, cancel := withTimeout(ctx, c.timeouts.Default)
+	defer cancel()
+	resp, err := c.cli.VolumeList(ctx, volume.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Volumes, nil
+}
+
+// VolumeInspect returns the details of the volume with the given name.
+// The function returns an error if the volume cannot be inspected.
+func (c *Client) VolumeInspect(ctx context.Context, name string) (volume.Volume, error) {
+	ctx, cancel := withTimeout(ctx, c.timeouts.Default)
+	defer cancel()
+	return c.cli.VolumeInspect(ctx, name)
+}
+
+// CreateVolume creates a volume with the given name and driver.
+// An empty name lets the daemon generate one, an empty driver selects the
+// default "local" driver.
+// The function returns the created volume, or an error if the creation fails.
+func (c *Client) CreateVolume(ctx context.Context, name, driver string) (volume.Volume, error) {
+	ctx, cancel := withTimeout(ctx, c.timeouts.Default)
+	defer cancel()
+	return c.cli.VolumeCreate(ctx, volume.CreateOptions{Name: name, Driver: driver})
+}
+
+// DeleteVolume deletes the volume with the given name.
+// It does not force the deletion, so a volume in use is not deleted.
+// The function returns an error if the deletion fails.
+func (c *Client) DeleteVolume(ctx context.Context, name string) error {
+	ctx, cancel := withTimeout(ctx, c.timeouts.Default)
+	defer cancel()
+	return c.cli.VolumeRemove(ctx, name, false)
+}
+
+// VolumesSize returns the size on disk of every volume, by name, as computed
+// by the DiskUsage API. Volumes whose size is unknown, e.g. because their
+// driver does not report it, are left out.
+// The function returns an error if the disk usage cannot be retrieved.
+func (c *Client) VolumesSize(ctx context.Context) (map[string]int64, error) {
+	ctx, cancel := withTimeout(ctx, c.timeouts.Default)
+	defer cancel()
+	du, err := c.cli.DiskUsage(ctx, types.DiskUsageOptions{Types: []types.DiskUsageObject{types.VolumeObject}})
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]int64, len(du.Volumes))
+	for _, v := range du.Volumes {
+		if v == nil || v.UsageData == nil || v.UsageData.Size < 0 {
+			continue
+		}
+		out[v.Name] = v.UsageData.Size
+	}
+	return out, nil
+}
@@ -1,7 +1,82 @@
 package types
 
-import "github.com/docker/docker/api/types/container"
+import (
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+// Labels set by docker compose on the containers it creates.
+const (
+	ComposeProjectLabel = "com.docker.compose.project" // ComposeProjectLabel holds the name of the compose project.
+	ComposeServiceLabel = "com.docker.compose.service" // ComposeServiceLabel holds the name of the compose service.
+)
+
+// Labels configuring how gmd updates a container.
+const (
+	UpdatePolicyLabel = "gmd.update.policy" // UpdatePolicyLabel holds the UpdatePolicy of the container.
+	UpdateTrackLabel  = "gmd.update.track"  // UpdateTrackLabel holds the tag the container is updated to, instead of the tag it runs.
+)
+
+// UpdatePolicy tells how gmd updates a container.
+type UpdatePolicy string
+
+const (
+	UpdatePolicyNotify UpdatePolicy = "notify" // UpdatePolicyNotify reports available updates, which run on demand. It is the default.
+	UpdatePolicySkip   UpdatePolicy = "skip"   // UpdatePolicySkip never checks nor updates the container.
+	UpdatePolicyAuto   UpdatePolicy = "auto"   // UpdatePolicyAuto lets gmd update the container without asking.
+)
 
 type Container struct {
 	container.InspectResponse
+	Host string // Host is the name of the daemon endpoint the container comes from.
+}
+
+// Label returns the value of the given label of the container, or an empty
+// string if the container has no such label.
+func (c Container) Label(name string) string {
+	if c.Config == nil {
+		return ""
+	}
+	return c.Config.Labels[name]
+}
+
+// Project returns the compose project of the container, or an empty string
+// if the container was not created by docker compose.
+func (c Container) Project() string {
+	return c.Label(ComposeProjectLabel)
+}
+
+// UpdatePolicy returns the update policy of the container, from its
+// gmd.update.policy label. A missing or unknown policy is UpdatePolicyNotify.
+func (c Container) UpdatePolicy() UpdatePolicy {
+	switch p := UpdatePolicy(strings.ToLower(strings.TrimSpace(c.Label(UpdatePolicyLabel)))); p {
+	case UpdatePolicySkip, UpdatePolicyAuto:
+		return p
+	default:
+		return UpdatePolicyNotify
+	}
+}
+
+// UpdateImage returns the reference of the image the container is updated
+// to: its image, with the tag replaced by the gmd.update.track label if the
+// container has one.
+func (c Container) UpdateImage() string {
+	if c.Config == nil {
+		return ""
+	}
+	tag := strings.TrimSpace(c.Label(UpdateTrackLabel))
+	if tag == "" {
+		return c.Config.Image
+	}
+
+	ref := c.Config.Image
+	if i := strings.Index(ref, "@"); i >= 0 {
+		ref = ref[:i]
+	}
+	// a colon before the last slash separates the port of the registry.
+	if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+		ref = ref[:i]
+	}
+	return ref + ":" + tag
 }
@@ -6,6 +6,7 @@ type Image struct {
 	RepoDigests []string
 	Size        int64
 	ParentID    string
+	Host        string // Host is the name of the daemon endpoint the image comes from.
 }
 
 func (img Image) Tag() string {
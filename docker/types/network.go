@@ -0,0 +1,18 @@
+package types
+
+import "github.com/docker/docker/api/types/network"
+
+type Network struct {
+	network.Inspect
+	Host string // Host is the name of the daemon endpoint the network comes from.
+}
+
+// Predefined reports whether the network is one of the networks the daemon
+// creates by itself, which cannot be removed.
+func (n Network) Predefined() bool {
+	switch n.Name {
+	case "bridge", "host", "none":
+		return true
+	}
+	return false
+}
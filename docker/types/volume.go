@@ -0,0 +1,8 @@
+package types
+
+import "github.com/docker/docker/api/types/volume"
+
+type Volume struct {
+	volume.Volume
+	Host string // Host is the name of the daemon endpoint the volume comes from.
+}
@@ -0,0 +1,155 @@
+// Package history records every container update attempt in an append-only
+// JSON Lines file, $XDG_DATA_HOME/gmd/history.jsonl by default, so the
+// updates can be audited once their screen is closed.
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Image identifies the image of a container.
+type Image struct {
+	Ref    string `json:"ref"`              // Ref is the reference of the image, e.g. nginx:1.27.
+	ID     string `json:"id"`               // ID is the ID of the image.
+	Digest string `json:"digest,omitempty"` // Digest is the repository digest of the image.
+}
+
+// Entry is an update attempt of a container.
+type Entry struct {
+	Container string    `json:"container"`       // Container is the name of the container.
+	Host      string    `json:"host"`            // Host is the name of the daemon endpoint of the container.
+	Old       Image     `json:"old"`             // Old is the image the container ran before the update.
+	New       Image     `json:"new"`             // New is the image pulled by the update, empty if the pull failed.
+	Started   time.Time `json:"started"`         // Started is when the update started.
+	Ended     time.Time `json:"ended"`           // Ended is when the update ended.
+	Steps     []string  `json:"steps"`           // Steps are the lines of the update.
+	Result    string    `json:"result"`          // Result is the outcome of the update: succeeded, failed, rolled back or canceled.
+	Operator  string    `json:"operator"`        // Operator is who ran the update.
+	Error     string    `json:"error,omitempty"` // Error is the last line of a failed update.
+}
+
+// Short returns the first 12 hexadecimal digits of the digest of the image,
+// or of its ID if it has no digest, or "-" if neither is known.
+func (i Image) Short() string {
+	id := i.Digest
+	if id == "" {
+		id = i.ID
+	}
+	if id == "" {
+		return "-"
+	}
+	_, hex, ok := strings.Cut(id, ":")
+	if !ok {
+		hex = id
+	}
+	return hex[:min(len(hex), 12)]
+}
+
+// Image returns the reference of the image the update pulled, or of the
+// image the container ran if the pull failed.
+func (e Entry) Image() string {
+	if e.New.Ref != "" {
+		return e.New.Ref
+	}
+	return e.Old.Ref
+}
+
+// DefaultPath returns the path of the history file: gmd/history.jsonl under
+// $XDG_DATA_HOME, or under ~/.local/share if it is not set.
+func DefaultPath() string {
+	dir := os.Getenv("XDG_DATA_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dir, "gmd", "history.jsonl")
+}
+
+// Operator returns the name of the user running gmd, suffixed by the given
+// mode if it is not empty, e.g. "alice (watch)".
+func Operator(mode string) string {
+	name := os.Getenv("USER")
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+	if name == "" {
+		name = "unknown"
+	}
+	if mode != "" {
+		name += " (" + mode + ")"
+	}
+	return name
+}
+
+// mu serializes the writes of the updates run at once by a batch.
+var mu sync.Mutex
+
+// Append adds the given entry at the end of the history file at the given
+// path, creating the file and its directory if needed.
+// It returns an error if the file could not be written.
+func Append(path string, e Entry) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(line); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Load returns the entries of the history file at the given path, oldest
+// first. A missing file is an empty history.
+// It returns an error if the file could not be read or a line could not be
+// parsed.
+func Load(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return entries, fmt.Errorf("%s:%d: %w", path, n, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
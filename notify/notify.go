@@ -0,0 +1,238 @@
+// Package notify sends the notifications of gmd watch: updates available,
+// and updates or rollbacks that failed, to webhooks, mail, ntfy and Gotify.
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kdruelle/gmd/config"
+)
+
+// Kind is the kind of an event.
+type Kind string
+
+const (
+	KindUpdateAvailable Kind = "update-available" // KindUpdateAvailable is sent when a newer image is found for a container.
+	KindUpdateFailed    Kind = "update-failed"    // KindUpdateFailed is sent when an update failed and the container could not be restored.
+	KindRolledBack      Kind = "rolled-back"      // KindRolledBack is sent when an update failed and the container was restored.
+)
+
+// Event is what a notification is about.
+type Event struct {
+	Kind      Kind      `json:"kind"`              // Kind is the kind of the event.
+	Host      string    `json:"host"`              // Host is the name of the daemon endpoint of the container.
+	Container string    `json:"container"`         // Container is the name of the container.
+	Image     string    `json:"image"`             // Image is the reference of the image the container is updated to.
+	Digest    string    `json:"digest,omitempty"`  // Digest is the registry digest of the available image.
+	Message   string    `json:"message,omitempty"` // Message explains why an update failed.
+	Time      time.Time `json:"time"`              // Time is when the event happened.
+}
+
+// Title returns a one-line summary of the event.
+func (e Event) Title() string {
+	switch e.Kind {
+	case KindUpdateAvailable:
+		return fmt.Sprintf("Update available for %s", e.Container)
+	case KindRolledBack:
+		return fmt.Sprintf("Update of %s failed, rolled back", e.Container)
+	default:
+		return fmt.Sprintf("Update of %s failed", e.Container)
+	}
+}
+
+// Text returns the description of the event.
+func (e Event) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Container: %s\n", e.Container)
+	if e.Host != "" {
+		fmt.Fprintf(&b, "Host: %s\n", e.Host)
+	}
+	fmt.Fprintf(&b, "Image: %s\n", e.Image)
+	if e.Digest != "" {
+		fmt.Fprintf(&b, "Digest: %s\n", e.Digest)
+	}
+	if e.Message != "" {
+		fmt.Fprintf(&b, "Reason: %s\n", e.Message)
+	}
+	return b.String()
+}
+
+// Notifier sends notifications.
+type Notifier interface {
+	// Notify sends a notification of the given event.
+	// It returns an error if the notification could not be sent.
+	Notify(ctx context.Context, e Event) error
+}
+
+// requestTimeout bounds the duration of a notification.
+const requestTimeout = 30 * time.Second
+
+// httpClient is the client of the HTTP backends.
+var httpClient = &http.Client{Timeout: requestTimeout}
+
+// Dispatcher sends every event to several notifiers. An available update is
+// only notified once per container and digest.
+type Dispatcher struct {
+	notifiers []Notifier
+	mu        sync.Mutex
+	notified  map[string]string // notified is the last digest notified available, by host and container name.
+	path      string            // path is the file notified is saved to, empty to keep it in memory only.
+}
+
+// NewDispatcher returns a dispatcher sending the events to the given
+// notifiers.
+func NewDispatcher(notifiers ...Notifier) *Dispatcher {
+	return &Dispatcher{
+		notifiers: notifiers,
+		notified:  make(map[string]string),
+	}
+}
+
+// New returns a dispatcher sending the events to every backend of the given
+// configuration.
+// It returns an error if a backend is misconfigured.
+func New(cfg config.Notify) (*Dispatcher, error) {
+	var notifiers []Notifier
+	for _, c := range cfg.Webhooks {
+		n, err := NewWebhook(c)
+		if err != nil {
+			return nil, err
+		}
+		notifiers = append(notifiers, n)
+	}
+	for _, c := range cfg.SMTP {
+		n, err := NewSMTP(c)
+		if err != nil {
+			return nil, err
+		}
+		notifiers = append(notifiers, n)
+	}
+	for _, c := range cfg.Ntfy {
+		n, err := NewNtfy(c)
+		if err != nil {
+			return nil, err
+		}
+		notifiers = append(notifiers, n)
+	}
+	for _, c := range cfg.Gotify {
+		n, err := NewGotify(c)
+		if err != nil {
+			return nil, err
+		}
+		notifiers = append(notifiers, n)
+	}
+	return NewDispatcher(notifiers...), nil
+}
+
+// Len returns the number of notifiers of the dispatcher.
+func (d *Dispatcher) Len() int {
+	return len(d.notifiers)
+}
+
+// Restore loads the available updates already notified from the file at the
+// given path, and saves them there from now on, so that they are not
+// notified again once gmd watch restarts. A missing file is no update
+// notified.
+// It returns an error if the file could not be read or parsed.
+func (d *Dispatcher) Restore(path string) error {
+	notified := make(map[string]string)
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return err
+	default:
+		if err := json.Unmarshal(data, &notified); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.notified = notified
+	d.path = path
+	return nil
+}
+
+// save writes the notified updates to the file of the dispatcher, if any,
+// replacing it at once. d.mu must be held.
+// It returns an error if the file could not be written.
+func (d *Dispatcher) save() error {
+	if d.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(d.notified, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(d.path), 0o700); err != nil {
+		return err
+	}
+	tmp := d.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, d.path)
+}
+
+// Notify sends the given event to every notifier, unless it is an available
+// update already notified with the same digest.
+// It returns the errors of the notifiers that failed, and of the save of
+// the notified updates, joined. An available update is notified again at
+// the next call if every notifier failed.
+func (d *Dispatcher) Notify(ctx context.Context, e Event) error {
+	if len(d.notifiers) == 0 {
+		return nil
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	key := e.Host + "/" + e.Container
+	var saveErr error
+	if e.Kind == KindUpdateAvailable {
+		d.mu.Lock()
+		if d.notified[key] == e.Digest {
+			d.mu.Unlock()
+			return nil
+		}
+		d.notified[key] = e.Digest
+		saveErr = d.save()
+		d.mu.Unlock()
+	}
+
+	var errs []error
+	for _, n := range d.notifiers {
+		if err := n.Notify(ctx, e); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if e.Kind == KindUpdateAvailable && len(errs) == len(d.notifiers) {
+		d.mu.Lock()
+		if d.notified[key] == e.Digest {
+			delete(d.notified, key)
+			saveErr = d.save()
+		}
+		d.mu.Unlock()
+	}
+	return errors.Join(append(errs, saveErr)...)
+}
+
+// checkStatus returns an error if the given response is not a success.
+func checkStatus(name string, resp *http.Response) error {
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s: %s", name, resp.Status)
+	}
+	return nil
+}
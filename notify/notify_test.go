@@ -0,0 +1,120 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+// recorder is a notifier recording the events it is sent, failing if err
+// is set.
+type recorder struct {
+	events []Event
+	err    error
+}
+
+func (r *recorder) Notify(ctx context.Context, e Event) error {
+	r.events = append(r.events, e)
+	return r.err
+}
+
+func TestDispatcherNotify(t *testing.T) {
+	available := func(container, digest string) Event {
+		return Event{Kind: KindUpdateAvailable, Host: "local", Container: container, Digest: digest}
+	}
+	failed := Event{Kind: KindUpdateFailed, Host: "local", Container: "web"}
+
+	tests := []struct {
+		name   string
+		events []Event
+		want   int // want is the number of events sent to the notifier.
+	}{
+		{"same digest once", []Event{available("web", "sha256:1"), available("web", "sha256:1")}, 1},
+		{"new digest again", []Event{available("web", "sha256:1"), available("web", "sha256:2")}, 2},
+		{"per container", []Event{available("web", "sha256:1"), available("db", "sha256:1")}, 2},
+		{"failures always", []Event{failed, failed}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &recorder{}
+			d := NewDispatcher(r)
+			for _, e := range tt.events {
+				if err := d.Notify(context.Background(), e); err != nil {
+					t.Fatalf("Notify: %v", err)
+				}
+			}
+			if len(r.events) != tt.want {
+				t.Errorf("sent %d events, want %d", len(r.events), tt.want)
+			}
+			for _, e := range r.events {
+				if e.Time.IsZero() {
+					t.Errorf("event %+v has no time", e)
+				}
+			}
+		})
+	}
+}
+
+func TestDispatcherNotifyFailed(t *testing.T) {
+	e := Event{Kind: KindUpdateAvailable, Host: "local", Container: "web", Digest: "sha256:1"}
+
+	tests := []struct {
+		name string
+		errs []error // errs are the errors of the notifiers.
+		want int     // want is the number of events sent to every notifier.
+	}{
+		{"every notifier failed", []error{errors.New("down"), errors.New("down")}, 2},
+		{"one notifier failed", []error{errors.New("down"), nil}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var notifiers []Notifier
+			var recorders []*recorder
+			for _, err := range tt.errs {
+				r := &recorder{err: err}
+				notifiers = append(notifiers, r)
+				recorders = append(recorders, r)
+			}
+			d := NewDispatcher(notifiers...)
+
+			if err := d.Notify(context.Background(), e); err == nil {
+				t.Fatal("Notify succeeded, want the errors of the notifiers")
+			}
+			_ = d.Notify(context.Background(), e)
+
+			for i, r := range recorders {
+				if len(r.events) != tt.want {
+					t.Errorf("notifier %d was sent %d events, want %d", i, len(r.events), tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestDispatcherRestore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gmd", "notified.json")
+	e := Event{Kind: KindUpdateAvailable, Host: "local", Container: "web", Digest: "sha256:1"}
+
+	r := &recorder{}
+	d := NewDispatcher(r)
+	if err := d.Restore(path); err != nil {
+		t.Fatalf("Restore of a missing file: %v", err)
+	}
+	if err := d.Notify(context.Background(), e); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+
+	// a restarted watch does not notify the same update again.
+	d = NewDispatcher(r)
+	if err := d.Restore(path); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	_ = d.Notify(context.Background(), e)
+	e.Digest = "sha256:2"
+	_ = d.Notify(context.Background(), e)
+
+	if len(r.events) != 2 {
+		t.Errorf("sent %d events, want 2", len(r.events))
+	}
+}
@@ -0,0 +1,109 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/kdruelle/gmd/config"
+)
+
+// Ntfy publishes the events to a ntfy topic.
+type Ntfy struct {
+	cfg config.Ntfy
+}
+
+// NewNtfy returns a notifier publishing to the topic of the given
+// configuration.
+// It returns an error if the URL is missing.
+func NewNtfy(cfg config.Ntfy) (*Ntfy, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("ntfy: url is required")
+	}
+	return &Ntfy{cfg: cfg}, nil
+}
+
+func (n *Ntfy) Notify(ctx context.Context, e Event) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.cfg.URL, strings.NewReader(e.Text()))
+	if err != nil {
+		return fmt.Errorf("ntfy: %w", err)
+	}
+	req.Header.Set("Title", e.Title())
+	req.Header.Set("Tags", ntfyTag(e.Kind))
+	if n.cfg.Priority > 0 {
+		req.Header.Set("Priority", strconv.Itoa(n.cfg.Priority))
+	}
+	if n.cfg.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+n.cfg.Token)
+	}
+
+	return send("ntfy", req)
+}
+
+// ntfyTag returns the tag of the given kind of event, shown as an emoji by
+// ntfy.
+func ntfyTag(k Kind) string {
+	switch k {
+	case KindUpdateAvailable:
+		return "package"
+	case KindRolledBack:
+		return "warning"
+	default:
+		return "rotating_light"
+	}
+}
+
+// Gotify pushes the events to a Gotify server.
+type Gotify struct {
+	cfg config.Gotify
+}
+
+// NewGotify returns a notifier pushing to the server of the given
+// configuration.
+// It returns an error if the URL or the token is missing.
+func NewGotify(cfg config.Gotify) (*Gotify, error) {
+	if cfg.URL == "" || cfg.Token == "" {
+		return nil, errors.New("gotify: url and token are required")
+	}
+	return &Gotify{cfg: cfg}, nil
+}
+
+func (g *Gotify) Notify(ctx context.Context, e Event) error {
+	body, err := json.Marshal(map[string]any{
+		"title":    e.Title(),
+		"message":  e.Text(),
+		"priority": g.cfg.Priority,
+	})
+	if err != nil {
+		return err
+	}
+
+	url := strings.TrimSuffix(g.cfg.URL, "/") + "/message"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("gotify: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Gotify-Key", g.cfg.Token)
+
+	return send("gotify", req)
+}
+
+// send sends the given request of the named backend.
+// It returns an error if the request failed or was not a success.
+func send(name string, req *http.Request) error {
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	return checkStatus(name, resp)
+}
@@ -0,0 +1,115 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kdruelle/gmd/config"
+)
+
+func TestNtfyNotify(t *testing.T) {
+	e := Event{Kind: KindUpdateAvailable, Host: "local", Container: "web", Image: "nginx:1.27", Digest: "sha256:1"}
+
+	tests := []struct {
+		name    string
+		cfg     config.Ntfy
+		status  int
+		want    http.Header // want are the headers expected, an empty value for an absent header.
+		wantErr bool
+	}{
+		{"published", config.Ntfy{}, http.StatusOK, http.Header{"Title": {e.Title()}, "Tags": {"package"}, "Priority": {""}, "Authorization": {""}}, false},
+		{"token and priority", config.Ntfy{Token: "tk_1", Priority: 4}, http.StatusOK, http.Header{"Priority": {"4"}, "Authorization": {"Bearer tk_1"}}, false},
+		{"forbidden", config.Ntfy{}, http.StatusForbidden, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				header http.Header
+				body   []byte
+			)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				header = r.Header
+				body, _ = io.ReadAll(r.Body)
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			tt.cfg.URL = srv.URL + "/gmd"
+			n, err := NewNtfy(tt.cfg)
+			if err != nil {
+				t.Fatalf("NewNtfy: %v", err)
+			}
+			err = n.Notify(context.Background(), e)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Notify: error %v, want error %v", err, tt.wantErr)
+			}
+
+			if string(body) != e.Text() {
+				t.Errorf("body %q, want %q", body, e.Text())
+			}
+			for k := range tt.want {
+				if header.Get(k) != tt.want.Get(k) {
+					t.Errorf("header %s: %q, want %q", k, header.Get(k), tt.want.Get(k))
+				}
+			}
+		})
+	}
+}
+
+func TestGotifyNotify(t *testing.T) {
+	e := Event{Kind: KindUpdateFailed, Host: "local", Container: "web", Image: "nginx:1.27", Message: "exited with code 1"}
+
+	tests := []struct {
+		name    string
+		url     string // url is appended to the URL of the server.
+		status  int
+		wantErr bool
+	}{
+		{"pushed", "", http.StatusOK, false},
+		{"trailing slash", "/", http.StatusOK, false},
+		{"unauthorized", "", http.StatusUnauthorized, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				path, key string
+				got       struct {
+					Title    string `json:"title"`
+					Message  string `json:"message"`
+					Priority int    `json:"priority"`
+				}
+			)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				path, key = r.URL.Path, r.Header.Get("X-Gotify-Key")
+				if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+					t.Errorf("decode body: %v", err)
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			n, err := NewGotify(config.Gotify{URL: srv.URL + tt.url, Token: "app", Priority: 5})
+			if err != nil {
+				t.Fatalf("NewGotify: %v", err)
+			}
+			err = n.Notify(context.Background(), e)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Notify: error %v, want error %v", err, tt.wantErr)
+			}
+
+			if path != "/message" {
+				t.Errorf("path %q, want /message", path)
+			}
+			if key != "app" {
+				t.Errorf("X-Gotify-Key %q, want app", key)
+			}
+			if got.Title != e.Title() || got.Message != e.Text() || got.Priority != 5 {
+				t.Errorf("body %+v", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package notify
+
+import (
+	"context"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"github.com/kdruelle/gmd/config"
+)
+
+// smtpServer is a minimal SMTP server accepting a single mail, without
+// STARTTLS nor authentication.
+type smtpServer struct {
+	ln   net.Listener
+	from string
+	to   []string
+	data string
+	done chan struct{}
+}
+
+func newSMTPServer(t *testing.T) *smtpServer {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	s := &smtpServer{ln: ln, done: make(chan struct{})}
+	t.Cleanup(func() { ln.Close() })
+	go s.serve()
+	return s
+}
+
+// port returns the port the server listens on.
+func (s *smtpServer) port() int {
+	return s.ln.Addr().(*net.TCPAddr).Port
+}
+
+func (s *smtpServer) serve() {
+	defer close(s.done)
+	conn, err := s.ln.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	tp := textproto.NewConn(conn)
+	_ = tp.PrintfLine("220 localhost ESMTP")
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			_ = tp.PrintfLine("250-localhost")
+			_ = tp.PrintfLine("250 8BITMIME")
+		case "MAIL":
+			s.from = smtpPath(arg)
+			_ = tp.PrintfLine("250 OK")
+		case "RCPT":
+			s.to = append(s.to, smtpPath(arg))
+			_ = tp.PrintfLine("250 OK")
+		case "DATA":
+			_ = tp.PrintfLine("354 go ahead")
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			s.data = string(data)
+			_ = tp.PrintfLine("250 OK")
+		case "QUIT":
+			_ = tp.PrintfLine("221 bye")
+			return
+		default:
+			_ = tp.PrintfLine("502 unknown command")
+		}
+	}
+}
+
+// smtpPath returns the address of the given MAIL or RCPT argument, e.g.
+// gmd@example.com for FROM:<gmd@example.com> BODY=8BITMIME.
+func smtpPath(arg string) string {
+	_, addr, _ := strings.Cut(arg, "<")
+	addr, _, _ = strings.Cut(addr, ">")
+	return addr
+}
+
+func TestSMTPNotify(t *testing.T) {
+	e := Event{Kind: KindUpdateAvailable, Host: "local", Container: "web", Image: "nginx:1.27", Digest: "sha256:1"}
+
+	tests := []struct {
+		name       string
+		from       string
+		to         []string
+		wantFrom   string
+		wantTo     []string
+		wantHeader string
+	}{
+		{"bare addresses", "gmd@example.com", []string{"ops@example.com"}, "gmd@example.com", []string{"ops@example.com"}, "From: <gmd@example.com>"},
+		{"named addresses", "gmd <gmd@example.com>", []string{"Ops <ops@example.com>", "dev@example.com"}, "gmd@example.com", []string{"ops@example.com", "dev@example.com"}, `From: "gmd" <gmd@example.com>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newSMTPServer(t)
+
+			n, err := NewSMTP(config.SMTP{Host: "127.0.0.1", Port: srv.port(), From: tt.from, To: tt.to})
+			if err != nil {
+				t.Fatalf("NewSMTP: %v", err)
+			}
+			if err := n.Notify(context.Background(), e); err != nil {
+				t.Fatalf("Notify: %v", err)
+			}
+			<-srv.done
+
+			if srv.from != tt.wantFrom {
+				t.Errorf("MAIL FROM %q, want %q", srv.from, tt.wantFrom)
+			}
+			if strings.Join(srv.to, ",") != strings.Join(tt.wantTo, ",") {
+				t.Errorf("RCPT TO %q, want %q", srv.to, tt.wantTo)
+			}
+			header, body, _ := strings.Cut(srv.data, "\n\n")
+			if !strings.Contains(header, tt.wantHeader+"\n") {
+				t.Errorf("headers %q, want %q", header, tt.wantHeader)
+			}
+			if !strings.Contains(header, "Subject: [gmd] "+e.Title()+"\n") {
+				t.Errorf("headers %q, want the title in the subject", header)
+			}
+			if body != e.Text() {
+				t.Errorf("body %q, want %q", body, e.Text())
+			}
+		})
+	}
+}
+
+func TestNewSMTP(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.SMTP
+		wantErr bool
+	}{
+		{"valid", config.SMTP{Host: "smtp.example.com", From: "gmd@example.com", To: []string{"ops@example.com"}}, false},
+		{"missing to", config.SMTP{Host: "smtp.example.com", From: "gmd@example.com"}, true},
+		{"invalid from", config.SMTP{Host: "smtp.example.com", From: "gmd", To: []string{"ops@example.com"}}, true},
+		{"invalid to", config.SMTP{Host: "smtp.example.com", From: "gmd@example.com", To: []string{"ops@"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := NewSMTP(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewSMTP: error %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && n.cfg.Port != 587 {
+				t.Errorf("port %d, want 587 by default", n.cfg.Port)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/kdruelle/gmd/config"
+)
+
+// Webhook posts the events as JSON to an URL.
+type Webhook struct {
+	cfg config.Webhook
+}
+
+// NewWebhook returns a notifier posting to the webhook of the given
+// configuration.
+// It returns an error if the URL is missing.
+func NewWebhook(cfg config.Webhook) (*Webhook, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("webhook: url is required")
+	}
+	return &Webhook{cfg: cfg}, nil
+}
+
+func (w *Webhook) Notify(ctx context.Context, e Event) error {
+	payload := struct {
+		Event
+		Title string `json:"title"`
+		Text  string `json:"text"`
+	}{e, e.Title(), e.Text()}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("webhook: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range w.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+
+	return send("webhook", req)
+}
@@ -0,0 +1,63 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kdruelle/gmd/config"
+)
+
+func TestWebhookNotify(t *testing.T) {
+	e := Event{Kind: KindRolledBack, Host: "local", Container: "web", Image: "nginx:1.27", Message: "unhealthy"}
+
+	tests := []struct {
+		name    string
+		status  int
+		headers map[string]string
+		wantErr bool
+	}{
+		{"posted", http.StatusOK, nil, false},
+		{"headers", http.StatusNoContent, map[string]string{"Authorization": "Bearer secret"}, false},
+		{"server error", http.StatusInternalServerError, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				got    map[string]any
+				header http.Header
+			)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				header = r.Header
+				if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+					t.Errorf("decode payload: %v", err)
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			n, err := NewWebhook(config.Webhook{URL: srv.URL, Headers: tt.headers})
+			if err != nil {
+				t.Fatalf("NewWebhook: %v", err)
+			}
+			err = n.Notify(context.Background(), e)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Notify: error %v, want error %v", err, tt.wantErr)
+			}
+
+			if got["kind"] != string(KindRolledBack) || got["container"] != "web" || got["title"] != e.Title() || got["text"] != e.Text() {
+				t.Errorf("payload %v", got)
+			}
+			if ct := header.Get("Content-Type"); ct != "application/json" {
+				t.Errorf("Content-Type %q", ct)
+			}
+			for k, v := range tt.headers {
+				if header.Get(k) != v {
+					t.Errorf("header %s: %q, want %q", k, header.Get(k), v)
+				}
+			}
+		})
+	}
+}
@@ -5,27 +5,36 @@ import (
 	"github.com/kdruelle/gmd/docker/cache"
 )
 
+// CacheStartMsg is sent once every cache of a group is loaded. Err joins the
+// errors of the hosts that could not be loaded.
 type CacheStartMsg struct {
-	Err error
+	Err   error
+	cache *cache.Group
 }
 
-func StartMonitorCache(m *cache.Cache) tea.Cmd {
+func StartMonitorCache(m *cache.Group) tea.Cmd {
 	return func() tea.Msg {
 		err := m.LoadAndStart()
-		return CacheStartMsg{Err: err}
+		return CacheStartMsg{Err: err, cache: m}
 	}
 }
 
-func WaitDockerEvent(ch <-chan cache.Event) tea.Cmd {
-	return func() tea.Msg {
-		//var now = time.Now()
-		var e cache.Event
+// dockerEventMsg wraps a cache event with the cache group it comes from, so
+// events of a group closed by a context switch can be dropped.
+type dockerEventMsg struct {
+	cache *cache.Group
+	event cache.Event
+}
 
-		for {
-			e = <-ch
-			//if e.EventType != cache.ContainerStatsEventType || time.Since(now) > 1*time.Second {
-			return e
-			//}
+// WaitDockerEvent waits for the next event of the given cache group.
+// It returns a nil message once the group is closed.
+func WaitDockerEvent(c *cache.Group) tea.Cmd {
+	return func() tea.Msg {
+		select {
+		case e := <-c.Events():
+			return dockerEventMsg{cache: c, event: e}
+		case <-c.Done():
+			return nil
 		}
 	}
 }
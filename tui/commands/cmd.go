@@ -1,7 +1,10 @@
 package commands
 
 import (
+	"context"
+
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kdruelle/gmd/docker/cache"
 	"github.com/kdruelle/gmd/docker/client"
 )
 
@@ -15,17 +18,36 @@ func SwitchPageCmd(modelCreate func() tea.Model) tea.Cmd {
 	}
 }
 
-func ContainerCmd(cli *client.Client, action Action, id string) tea.Cmd {
+// ReloadCmd returns a command asking the root model to reload the daemons.
+func ReloadCmd() tea.Cmd {
+	return func() tea.Msg {
+		return ReloadMsg{}
+	}
+}
+
+// WaitChangeCmd waits for the next change of the given cache subscription.
+// It returns a nil message once the subscription is closed.
+func WaitChangeCmd(sub <-chan cache.Change) tea.Cmd {
+	return func() tea.Msg {
+		ch, ok := <-sub
+		if !ok {
+			return nil
+		}
+		return ChangeMsg{Sub: sub, Change: ch}
+	}
+}
+
+func ContainerCmd(ctx context.Context, cli *client.Client, action Action, id string) tea.Cmd {
 	return func() tea.Msg {
-		msg := ContainerActionMsg{ContainerID: id, Action: action}
+		msg := ContainerActionMsg{Host: cli.Endpoint().Name, ContainerID: id, Action: action}
 
 		switch action {
 		case StartContainerAction:
-			msg.Err = cli.StartContainer(id)
+			msg.Err = cli.StartContainer(ctx, id)
 		case StopContainerAction:
-			msg.Err = cli.StopContainer(id)
+			msg.Err = cli.StopContainer(ctx, id)
 		case RestartContainerAction:
-			msg.Err = cli.RestartContainer(id)
+			msg.Err = cli.RestartContainer(ctx, id)
 		}
 		return msg
 	}
@@ -1,11 +1,32 @@
 package commands
 
-import tea "github.com/charmbracelet/bubbletea"
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kdruelle/gmd/docker/cache"
+	"github.com/kdruelle/gmd/docker/client"
+)
 
 type SwitchPageMsg struct {
 	Model tea.Model
 }
 
+// SwitchClientMsg asks the root model to drop the current daemon
+// connection and to reload every screen with the given client.
+type SwitchClientMsg struct {
+	Client *client.Client
+}
+
+// ReloadMsg asks the root model to reconnect to the current daemons and to
+// reload every screen, e.g. after the daemons failed to load.
+type ReloadMsg struct{}
+
+// ChangeMsg carries a change received on a cache subscription. Sub is the
+// subscription channel, so a model can tell its changes from others'.
+type ChangeMsg struct {
+	Sub    <-chan cache.Change
+	Change cache.Change
+}
+
 type Action string
 
 const (
@@ -15,6 +36,7 @@ const (
 )
 
 type ContainerActionMsg struct {
+	Host        string
 	ContainerID string
 	Action      Action
 	Update      bool
@@ -0,0 +1,7 @@
+package componants
+
+// Closable is implemented by models holding resources, such as pending
+// commands, that must be released when they leave the screen.
+type Closable interface {
+	Close()
+}
@@ -0,0 +1,45 @@
+package componants
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	style "github.com/kdruelle/gmd/tui/styles"
+)
+
+// Confirm asks the user to confirm an action before running it.
+// The zero Confirm is inactive.
+type Confirm struct {
+	prompt string
+	cmd    tea.Cmd
+	active bool
+}
+
+// Ask activates the confirmation with the given question. The given command
+// is returned by Update if the user confirms.
+func (c *Confirm) Ask(prompt string, cmd tea.Cmd) {
+	c.prompt = prompt
+	c.cmd = cmd
+	c.active = true
+}
+
+// Active reports whether the confirmation waits for an answer.
+func (c Confirm) Active() bool {
+	return c.active
+}
+
+// Update handles the answer of the user: "y" confirms and returns the
+// command of the action, any other key cancels it.
+func (c *Confirm) Update(msg tea.KeyMsg) tea.Cmd {
+	c.active = false
+	if msg.String() == "y" || msg.String() == "Y" {
+		return c.cmd
+	}
+	return nil
+}
+
+// View renders the question, or nothing when the confirmation is inactive.
+func (c Confirm) View() string {
+	if !c.active {
+		return ""
+	}
+	return style.Warning().Bold(true).Render(c.prompt + " (y/N)")
+}
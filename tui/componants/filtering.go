@@ -0,0 +1,7 @@
+package componants
+
+// Filtering is implemented by models whose list filter is being typed, which
+// must receive every key stroke, shortcuts included.
+type Filtering interface {
+	IsFiltering() bool
+}
@@ -0,0 +1,7 @@
+package componants
+
+// Prompting is implemented by models waiting for the user to answer a
+// prompt, which must receive every key stroke, shortcuts included.
+type Prompting interface {
+	IsPrompting() bool
+}
@@ -1,35 +1,45 @@
 package containerstats
 
 import (
+	"context"
 	"sync"
 	"time"
 
 	"github.com/alitto/pond/v2"
 	"github.com/docker/docker/api/types/container"
-	"github.com/kdruelle/gmd/docker/client"
+	"github.com/kdruelle/gmd/docker/cache"
 )
 
 type StatsMsg struct {
+	Host  string
 	ID    string
 	Stats container.StatsResponse
 }
 
+// containerKey identifies a container across hosts.
+type containerKey struct {
+	host string
+	id   string
+}
+
 type Controller struct {
+	ctx        context.Context
 	mu         sync.RWMutex
-	cli        *client.Client
+	cache      *cache.Group
 	pool       pond.Pool
 	delay      time.Duration
-	containers map[string]struct{}
+	containers map[containerKey]struct{}
 	events     chan StatsMsg
 }
 
-func New(cli *client.Client) *Controller {
-	pool := pond.NewPool(5, pond.WithQueueSize(5))
+func New(ctx context.Context, cache *cache.Group) *Controller {
+	pool := pond.NewPool(5, pond.WithQueueSize(5), pond.WithContext(ctx))
 	c := &Controller{
-		cli:        cli,
+		ctx:        ctx,
+		cache:      cache,
 		pool:       pool,
 		delay:      500 * time.Millisecond,
-		containers: make(map[string]struct{}),
+		containers: make(map[containerKey]struct{}),
 		events:     make(chan StatsMsg),
 	}
 	return c
@@ -43,15 +53,15 @@ func (c *Controller) Events() <-chan StatsMsg {
 	return c.events
 }
 
-func (c *Controller) AddContainer(id string) {
+func (c *Controller) AddContainer(host, id string) {
 	c.mu.Lock()
-	c.containers[id] = struct{}{}
+	c.containers[containerKey{host: host, id: id}] = struct{}{}
 	c.mu.Unlock()
 }
 
-func (c *Controller) RemoveContainer(id string) {
+func (c *Controller) RemoveContainer(host, id string) {
 	c.mu.Lock()
-	delete(c.containers, id)
+	delete(c.containers, containerKey{host: host, id: id})
 	c.mu.Unlock()
 }
 
@@ -63,26 +73,34 @@ func (c *Controller) loop() {
 		select {
 		case <-ticker.C:
 			c.poll()
+		case <-c.ctx.Done():
+			return
 		}
 	}
 }
 
 func (c *Controller) poll() {
 	c.mu.RLock()
-	ids := make([]string, 0, len(c.containers))
-	for id := range c.containers {
-		ids = append(ids, id)
+	keys := make([]containerKey, 0, len(c.containers))
+	for key := range c.containers {
+		keys = append(keys, key)
 	}
 	c.mu.RUnlock()
 
-	for _, id := range ids {
-		cid := id
+	for _, key := range keys {
+		cli := c.cache.Client(key.host)
+		if cli == nil {
+			continue
+		}
 		c.pool.Submit(func() {
-			stats, err := c.cli.ContainerStats(cid)
+			stats, err := cli.ContainerStats(c.ctx, key.id)
 			if err != nil {
 				return
 			}
-			c.events <- StatsMsg{ID: cid, Stats: stats}
+			select {
+			case c.events <- StatsMsg{Host: key.host, ID: key.id, Stats: stats}:
+			case <-c.ctx.Done():
+			}
 		})
 	}
 }
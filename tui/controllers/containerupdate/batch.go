@@ -0,0 +1,252 @@
+package containerupdate
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/kdruelle/gmd/config"
+	"github.com/kdruelle/gmd/docker/client"
+	"github.com/kdruelle/gmd/docker/types"
+)
+
+// Options configures how updates are run.
+type Options struct {
+	Parallel int                     // Parallel is the number of containers a batch updates at once.
+	Hooks    map[string]config.Hooks // Hooks are the configured hooks, by container name. The labels of a container take precedence.
+	History  string                  // History is the path of the history file the updates are recorded in, empty to record nothing.
+	Operator string                  // Operator is who runs the updates, as recorded in the history.
+
+	// HostHookLabels are the containers, by name or host/name, whose host
+	// hooks may come from their gmd.hooks.<stage>.host labels.
+	HostHookLabels []string
+	// ComposeHosts are the remote hosts whose compose containers are
+	// recreated with docker compose, see composeService.
+	ComposeHosts []string
+}
+
+// DefaultOptions are the options used when none are configured.
+var DefaultOptions = Options{
+	Parallel: 2,
+}
+
+// job is the update of a container of a batch.
+type job struct {
+	container  types.Container
+	controller *Controller
+	deps       []*job        // deps are the jobs of the containers the container depends on.
+	started    bool          // started is true once the update of the container started.
+	reason     string        // reason explains why the update was skipped.
+	done       chan struct{} // done is closed once the job ended.
+}
+
+// JobStatus is the state of the update of a container of a batch.
+type JobStatus struct {
+	Name    string // Name is the name of the container.
+	Host    string // Host is the name of the daemon endpoint of the container.
+	Started bool   // Started is true once the update of the container started.
+	Result  Result // Result is the outcome of the update, ResultPending until it ends.
+	Line    string // Line is the last line of the update, or why it was skipped.
+}
+
+// Batch updates several containers, possibly of several hosts, with a
+// bounded parallelism. The containers a container depends on, through its
+// network mode (container:X) or its links, are updated first. A container is
+// skipped if one of them could not be updated.
+// A failed update is rolled back without asking. Containers whose update
+// policy is types.UpdatePolicySkip are skipped.
+type Batch struct {
+	m          sync.RWMutex
+	updateChan chan ControllerUpdateMsg
+	jobs       []*job
+	parallel   int
+}
+
+// NewBatch returns a batch updating the given containers with the given
+// options. clients returns the client of the daemon of a host.
+func NewBatch(containers []types.Container, clients func(host string) *client.Client, opts Options) *Batch {
+	b := &Batch{
+		updateChan: make(chan ControllerUpdateMsg, 10),
+		parallel:   max(opts.Parallel, 1),
+	}
+
+	names := make(map[string]string, len(containers))
+	for _, c := range containers {
+		names[c.ID] = strings.TrimPrefix(c.Name, "/")
+	}
+
+	for _, c := range containers {
+		controller := New(clients(c.Host), opts)
+		controller.updateChan = b.updateChan
+		controller.autoRollback = true
+		controller.names = names
+		b.jobs = append(b.jobs, &job{
+			container:  c,
+			controller: controller,
+			done:       make(chan struct{}),
+		})
+	}
+
+	for _, j := range b.jobs {
+		for _, ref := range dependencies(j.container) {
+			dep := b.find(j.container.Host, ref)
+			if dep == nil || dep == j || dep.dependsOn(j) {
+				continue
+			}
+			j.deps = append(j.deps, dep)
+		}
+	}
+
+	return b
+}
+
+// dependencies returns the IDs or names of the containers the given
+// container depends on: the one whose network stack it shares and the
+// targets of its links.
+func dependencies(c types.Container) []string {
+	if c.HostConfig == nil {
+		return nil
+	}
+
+	var refs []string
+	if c.HostConfig.NetworkMode.IsContainer() {
+		refs = append(refs, c.HostConfig.NetworkMode.ConnectedContainer())
+	}
+	for _, link := range c.HostConfig.Links {
+		target, _, _ := strings.Cut(link, ":")
+		refs = append(refs, strings.TrimPrefix(target, "/"))
+	}
+	return refs
+}
+
+// find returns the job of the container of the given host whose ID, short
+// ID or name is ref, or nil if the batch does not update it.
+func (b *Batch) find(host, ref string) *job {
+	for _, j := range b.jobs {
+		if j.container.Host != host {
+			continue
+		}
+		if j.container.ID == ref || strings.TrimPrefix(j.container.Name, "/") == ref {
+			return j
+		}
+		if len(ref) >= 12 && strings.HasPrefix(j.container.ID, ref) {
+			return j
+		}
+	}
+	return nil
+}
+
+// dependsOn reports whether the job depends, even indirectly, on the given
+// job. It guards the batch against dependency cycles.
+func (j *job) dependsOn(other *job) bool {
+	for _, dep := range j.deps {
+		if dep == other || dep.dependsOn(other) {
+			return true
+		}
+	}
+	return false
+}
+
+// Events returns the channel notified when the state of the batch changes.
+// It is closed once every update ended.
+func (b *Batch) Events() <-chan ControllerUpdateMsg {
+	return b.updateChan
+}
+
+// Parallel returns the number of containers the batch updates at once.
+func (b *Batch) Parallel() int {
+	return b.parallel
+}
+
+// Start starts the updates in the background.
+// Canceling ctx skips the updates that did not start yet and aborts the
+// image pulls of the running ones.
+func (b *Batch) Start(ctx context.Context) {
+	go b.run(ctx)
+}
+
+func (b *Batch) run(ctx context.Context) {
+	defer close(b.updateChan)
+
+	sem := make(chan struct{}, b.parallel)
+	var wg sync.WaitGroup
+
+	for _, j := range b.jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer close(j.done)
+
+			if j.container.UpdatePolicy() == types.UpdatePolicySkip {
+				b.skip(j, "update policy is skip")
+				return
+			}
+
+			for _, dep := range j.deps {
+				<-dep.done
+				if dep.controller.Result() != ResultSucceeded {
+					b.skip(j, "dependency "+strings.TrimPrefix(dep.container.Name, "/")+" was not updated")
+					return
+				}
+			}
+
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				b.skip(j, "canceled")
+				return
+			}
+			defer func() { <-sem }()
+
+			if ctx.Err() != nil {
+				b.skip(j, "canceled")
+				return
+			}
+
+			b.m.Lock()
+			j.started = true
+			b.m.Unlock()
+
+			j.controller.done = ctx.Done()
+			j.controller.run(ctx, []types.Container{j.container})
+			j.controller.notify()
+		}()
+	}
+
+	wg.Wait()
+}
+
+// skip records that the update of the given job did not run.
+func (b *Batch) skip(j *job, reason string) {
+	b.m.Lock()
+	j.reason = reason
+	b.m.Unlock()
+
+	j.controller.m.Lock()
+	j.controller.result = ResultSkipped
+	j.controller.m.Unlock()
+	j.controller.notify()
+}
+
+// Status returns the state of the update of every container of the batch,
+// in the order they were given.
+func (b *Batch) Status() []JobStatus {
+	b.m.RLock()
+	defer b.m.RUnlock()
+
+	out := make([]JobStatus, 0, len(b.jobs))
+	for _, j := range b.jobs {
+		s := JobStatus{
+			Name:    strings.TrimPrefix(j.container.Name, "/"),
+			Host:    j.container.Host,
+			Started: j.started,
+			Result:  j.controller.Result(),
+			Line:    j.reason,
+		}
+		if lines := j.controller.GetLines(); s.Line == "" && len(lines) > 0 {
+			s.Line = lines[len(lines)-1]
+		}
+		out = append(out, s)
+	}
+	return out
+}
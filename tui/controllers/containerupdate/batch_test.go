@@ -0,0 +1,161 @@
+package containerupdate
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/kdruelle/gmd/docker/client"
+	"github.com/kdruelle/gmd/docker/types"
+)
+
+// testContainer returns a container of the given host with the given name
+// and ID, sharing the network stack of mode when it names a container and
+// linked to the given containers.
+func testContainer(host, name, id, mode string, links ...string) types.Container {
+	return types.Container{
+		Host: host,
+		InspectResponse: container.InspectResponse{
+			ContainerJSONBase: &container.ContainerJSONBase{
+				ID:         id,
+				Name:       "/" + name,
+				HostConfig: &container.HostConfig{NetworkMode: container.NetworkMode(mode), Links: links},
+			},
+			Config: &container.Config{Labels: map[string]string{}},
+		},
+	}
+}
+
+// depNames returns the names of the dependencies of every job of the
+// batch, by container name.
+func depNames(b *Batch) map[string][]string {
+	out := make(map[string][]string, len(b.jobs))
+	for _, j := range b.jobs {
+		name := j.container.Host + "/" + strings.TrimPrefix(j.container.Name, "/")
+		out[name] = []string{}
+		for _, dep := range j.deps {
+			out[name] = append(out[name], dep.container.Host+"/"+strings.TrimPrefix(dep.container.Name, "/"))
+		}
+	}
+	return out
+}
+
+func noClient(string) *client.Client { return nil }
+
+func TestNewBatchDependencies(t *testing.T) {
+	vpnID := "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+	tests := []struct {
+		name       string
+		containers []types.Container
+		want       map[string][]string
+	}{
+		{
+			name: "network mode by name",
+			containers: []types.Container{
+				testContainer("local", "app", "a1", "container:vpn"),
+				testContainer("local", "vpn", vpnID, "bridge"),
+			},
+			want: map[string][]string{"local/app": {"local/vpn"}, "local/vpn": {}},
+		},
+		{
+			name: "network mode by short ID",
+			containers: []types.Container{
+				testContainer("local", "app", "a1", "container:"+vpnID[:12]),
+				testContainer("local", "vpn", vpnID, "bridge"),
+			},
+			want: map[string][]string{"local/app": {"local/vpn"}, "local/vpn": {}},
+		},
+		{
+			name: "links",
+			containers: []types.Container{
+				testContainer("local", "web", "w1", "bridge", "/db:/web/database", "/cache:/web/cache"),
+				testContainer("local", "db", "d1", "bridge"),
+				testContainer("local", "cache", "c1", "bridge"),
+			},
+			want: map[string][]string{"local/web": {"local/db", "local/cache"}, "local/db": {}, "local/cache": {}},
+		},
+		{
+			name: "dependency not updated",
+			containers: []types.Container{
+				testContainer("local", "web", "w1", "bridge", "/db:/web/db"),
+			},
+			want: map[string][]string{"local/web": {}},
+		},
+		{
+			name: "dependency of another host",
+			containers: []types.Container{
+				testContainer("local", "web", "w1", "bridge", "/db:/web/db"),
+				testContainer("remote", "db", "d1", "bridge"),
+			},
+			want: map[string][]string{"local/web": {}, "remote/db": {}},
+		},
+		{
+			name: "self reference",
+			containers: []types.Container{
+				testContainer("local", "web", "w1", "container:web"),
+			},
+			want: map[string][]string{"local/web": {}},
+		},
+		{
+			name: "cycle",
+			containers: []types.Container{
+				testContainer("local", "a", "a1", "bridge", "/b:/a/b"),
+				testContainer("local", "b", "b1", "bridge", "/a:/b/a"),
+			},
+			want: map[string][]string{"local/a": {"local/b"}, "local/b": {}},
+		},
+		{
+			name: "indirect cycle",
+			containers: []types.Container{
+				testContainer("local", "a", "a1", "bridge", "/b:/a/b"),
+				testContainer("local", "b", "b1", "container:c"),
+				testContainer("local", "c", "c1", "bridge", "/a:/c/a"),
+			},
+			want: map[string][]string{"local/a": {"local/b"}, "local/b": {"local/c"}, "local/c": {}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBatch(tt.containers, noClient, DefaultOptions)
+			if got := depNames(b); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("dependencies = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBatchSkipsDependents(t *testing.T) {
+	db := testContainer("local", "db", "d1", "bridge")
+	db.Config.Labels[types.UpdatePolicyLabel] = string(types.UpdatePolicySkip)
+	web := testContainer("local", "web", "w1", "bridge", "/db:/web/db")
+	proxy := testContainer("local", "proxy", "p1", "container:web")
+
+	b := NewBatch([]types.Container{proxy, web, db}, noClient, DefaultOptions)
+
+	// canceled, the batch updates no container.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	b.Start(ctx)
+	for range b.Events() {
+	}
+
+	want := map[string]string{
+		"db":    "update policy is skip",
+		"web":   "dependency db was not updated",
+		"proxy": "dependency web was not updated",
+	}
+	for _, s := range b.Status() {
+		if s.Result != ResultSkipped {
+			t.Errorf("%s: result = %v, want skipped", s.Name, s.Result)
+		}
+		if s.Started {
+			t.Errorf("%s started", s.Name)
+		}
+		if s.Line != want[s.Name] {
+			t.Errorf("%s: line = %q, want %q", s.Name, s.Line, want[s.Name])
+		}
+	}
+}
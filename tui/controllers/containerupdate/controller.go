@@ -4,22 +4,52 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/charmbracelet/x/ansi"
 	"github.com/docker/docker/api/types/container"
 	"github.com/kdruelle/gmd/docker/client"
 	"github.com/kdruelle/gmd/docker/types"
+	"github.com/kdruelle/gmd/history"
 	style "github.com/kdruelle/gmd/tui/styles"
 )
 
 type ControllerUpdateMsg struct {
 }
 
-type createResponse struct {
-	Resp container.CreateResponse
-	Err  error
+// Result is the outcome of an update.
+type Result int
+
+const (
+	ResultPending    Result = iota // ResultPending is the result of an update that did not end yet.
+	ResultSucceeded                // ResultSucceeded is the result of an update whose containers were all updated.
+	ResultFailed                   // ResultFailed is the result of an update that failed without restoring the container.
+	ResultRolledBack               // ResultRolledBack is the result of an update that failed and restored the container.
+	ResultCanceled                 // ResultCanceled is the result of an update canceled before recreating the container.
+	ResultSkipped                  // ResultSkipped is the result of an update of a batch that did not run.
+)
+
+// String returns the name of the result, as used in logs.
+func (r Result) String() string {
+	switch r {
+	case ResultPending:
+		return "pending"
+	case ResultSucceeded:
+		return "succeeded"
+	case ResultFailed:
+		return "failed"
+	case ResultRolledBack:
+		return "rolled back"
+	case ResultCanceled:
+		return "canceled"
+	case ResultSkipped:
+		return "skipped"
+	default:
+		return fmt.Sprintf("Result(%d)", int(r))
+	}
 }
 
 type Controller struct {
@@ -30,12 +60,25 @@ type Controller struct {
 	order  []string
 	layers map[string]string
 	lines  []string
+	base   int // base is the number of lines of the containers updated before the current one.
+
+	done         <-chan struct{}   // done is closed when the update is canceled, e.g. when the update screen is closed.
+	decision     chan bool         // decision receives the answer to askRollback.
+	asking       bool              // asking is true while askRollback waits for an answer.
+	autoRollback bool              // autoRollback answers askRollback with a roll back, without asking.
+	rolledBack   bool              // rolledBack is true once a failed update restored the container.
+	result       Result            // result is the outcome of the update.
+	names        map[string]string // names are the names of containers by ID, for containers that share the network stack of a container recreated by the same batch.
+	pulled       history.Image     // pulled is the image pulled for the container being updated.
+	opts         Options
 }
 
-func New(client *client.Client) *Controller {
+func New(client *client.Client, opts Options) *Controller {
 	c := Controller{
 		cli:        client,
+		opts:       opts,
 		updateChan: make(chan ControllerUpdateMsg, 10),
+		decision:   make(chan bool),
 	}
 	return &c
 }
@@ -50,20 +93,96 @@ func (c *Controller) GetLines() []string {
 	return c.lines
 }
 
-func (c *Controller) StartUpdate(container types.Container) {
-	c.order = []string{}
-	c.layers = make(map[string]string)
-	go c.updateContainer(container)
+// StartUpdate starts updating the given containers one after the other in
+// the background. The update stops at the first container that fails.
+// Canceling ctx aborts the image pull. Once the pull is done the old
+// container is about to be stopped, so the remaining steps run to completion
+// regardless of ctx.
+func (c *Controller) StartUpdate(ctx context.Context, containers ...types.Container) {
+	c.done = ctx.Done()
+	go func() {
+		defer close(c.updateChan)
+
+		switch c.run(ctx, containers) {
+		case ResultSucceeded:
+			c.m.Lock()
+			c.lines = append(c.lines, "update complete, press enter to close...")
+			c.m.Unlock()
+		case ResultFailed, ResultRolledBack:
+			c.appendLine(style.Danger().Render("update failed, press enter to close..."))
+		}
+	}()
 }
 
-func (c *Controller) updateContainer(container types.Container) {
+// Result returns the outcome of the update, ResultPending until it ends.
+func (c *Controller) Result() Result {
+	c.m.RLock()
+	defer c.m.RUnlock()
+	return c.result
+}
+
+// notify tells the model the lines changed. Notifications are dropped when
+// nobody listens anymore, e.g. when the update screen was closed.
+func (c *Controller) notify() {
+	select {
+	case c.updateChan <- ControllerUpdateMsg{}:
+	default:
+	}
+}
+
+// run updates the given containers one after the other, until one of them
+// fails, and returns the result of the update.
+func (c *Controller) run(ctx context.Context, containers []types.Container) Result {
+	result := ResultSucceeded
+	for _, container := range containers {
+		if ctx.Err() != nil {
+			result = ResultCanceled
+			break
+		}
+		entry := c.newEntry(ctx, container)
+		first := len(c.GetLines())
+		if !c.updateContainer(ctx, container) {
+			result = ResultFailed
+			if ctx.Err() != nil && !c.rolledBack {
+				result = ResultCanceled
+			}
+			if c.rolledBack {
+				result = ResultRolledBack
+			}
+			if result != ResultCanceled {
+				_ = c.runHooks(context.WithoutCancel(ctx), StageOnFailure, container, container.ID)
+			}
+			c.record(entry, first, result)
+			break
+		}
+		c.record(entry, first, ResultSucceeded)
+	}
+
+	c.m.Lock()
+	c.result = result
+	c.m.Unlock()
+	return result
+}
 
+// updateContainer pulls the image the given container is updated to and
+// recreates it.
+// It reports whether the container was updated.
+func (c *Controller) updateContainer(ctx context.Context, container types.Container) bool {
 	containerName := strings.TrimPrefix(container.Name, "/")
+	ref := container.UpdateImage()
+	c.pulled = history.Image{}
 
-	done := make(chan error)
-	defer close(done)
+	if err := c.runHooks(ctx, StagePrePull, container, container.ID); err != nil {
+		return false
+	}
+
+	c.m.Lock()
+	c.order = []string{}
+	c.layers = make(map[string]string)
+	c.base = len(c.lines)
+	c.m.Unlock()
 
-	err := c.cli.PullImageWithProgress(context.Background(), container.Config.Image, func(msg map[string]interface{}) {
+	err := c.cli.PullImageWithProgress(ctx, ref, func(msg map[string]interface{}) {
 		var ok bool
 		var status, layerId string
 
@@ -90,7 +209,7 @@ func (c *Controller) updateContainer(container types.Container) {
 		}
 		c.layers[layerId] = line
 
-		c.lines = c.lines[:0]
+		c.lines = c.lines[:c.base]
 		for _, id := range c.order {
 			c.lines = append(c.lines, c.layers[id])
 		}
@@ -98,150 +217,477 @@ func (c *Controller) updateContainer(container types.Container) {
 
 		log.Printf("line: %s", line)
 
-		c.updateChan <- ControllerUpdateMsg{}
+		c.notify()
 	})
 
 	if err != nil {
-		log.Printf("Error pull for image %s : %v", container.Config.Image, err)
+		log.Printf("Error pull for image %s : %v", ref, err)
 		c.m.Lock()
 		c.lines = append(c.lines, fmt.Sprintf("Error pull image: %v", err))
 		c.m.Unlock()
-		c.updateChan <- ControllerUpdateMsg{}
-		return
+		c.notify()
+		return false
+	}
+
+	ctx = context.WithoutCancel(ctx)
+
+	if c.opts.History != "" {
+		if img, err := c.cli.LocalImage(ctx, ref, ref); err == nil {
+			c.pulled = history.Image{Ref: ref, ID: img.ID, Digest: img.Digest}
+		}
 	}
 
-	containerConfig, err := c.cli.ContainerInspect(container.ID)
+	containerConfig, err := c.cli.ContainerInspect(ctx, container.ID)
 	if err != nil {
 		log.Printf("Error get config for container %s : %v", container.ID, err)
-		c.m.Lock()
-		c.lines = append(c.lines, fmt.Sprintf("Error get config: %v", err))
-		c.m.Unlock()
-		c.updateChan <- ControllerUpdateMsg{}
+		c.appendLine(fmt.Sprintf("Error get config: %v", err))
+		return false
+	}
+
+	c.resolveNetworkContainer(containerConfig.HostConfig)
+
+	if err := c.runHooks(ctx, StagePreStop, container, container.ID); err != nil {
+		return false
+	}
+
+	// compose recreates the container with the image of the compose file,
+	// a container tracking another tag is recreated from its inspect.
+	tracking := ref != containerConfig.Config.Image
+	containerConfig.Config.Image = ref
+
+	if service, ok := composeService(ctx, c.cli, c.opts, containerConfig, tracking); ok {
+		return c.recreateWithCompose(ctx, container, containerName, containerConfig, service)
+	}
+
+	return c.recreate(ctx, container, containerName, containerConfig)
+}
+
+// composeService returns the compose service recreating the container of
+// the given configuration, and reports whether the update goes through
+// docker compose: the container was created by docker compose, does not
+// track another tag, docker compose can be run, and the daemon runs on this
+// machine, or its host is one of the ComposeHosts of the options, since the
+// compose files are read from this machine.
+func composeService(ctx context.Context, cli *client.Client, opts Options, config container.InspectResponse, tracking bool) (client.ComposeService, bool) {
+	endpoint := cli.Endpoint()
+	if tracking || !(endpoint.Local() || slices.Contains(opts.ComposeHosts, endpoint.Name)) {
+		return client.ComposeService{}, false
+	}
+	service, ok := client.ComposeServiceOf(config)
+	if !ok || !client.ComposeAvailable(ctx) {
+		return client.ComposeService{}, false
+	}
+	return service, true
+}
+
+// newEntry returns the history entry of the update of the given container,
+// starting now.
+func (c *Controller) newEntry(ctx context.Context, container types.Container) history.Entry {
+	entry := history.Entry{
+		Container: strings.TrimPrefix(container.Name, "/"),
+		Host:      container.Host,
+		Old:       history.Image{Ref: container.Config.Image, ID: container.Image},
+		Started:   time.Now(),
+		Operator:  c.opts.Operator,
+	}
+	if c.opts.History != "" {
+		if img, err := c.cli.LocalImage(ctx, container.Image, container.Config.Image); err == nil {
+			entry.Old.Digest = img.Digest
+		}
+	}
+	return entry
+}
+
+// record appends the given entry to the history, with the given result and
+// the lines from the given index as steps.
+func (c *Controller) record(entry history.Entry, first int, result Result) {
+	if c.opts.History == "" {
 		return
 	}
 
-	add := true
-	err = spinUntilDone(func() error {
-		return c.cli.StopContainer(container.ID)
-	}, func(frame string) {
-		c.m.Lock()
-		if add {
-			c.lines = append(c.lines, fmt.Sprintf("%s Stoping container: %s", frame, containerName))
-			add = false
-		} else {
-			c.lines[len(c.lines)-1] = fmt.Sprintf("%s Stoping container: %s", frame, containerName)
+	entry.Ended = time.Now()
+	entry.Result = result.String()
+	entry.New = c.pulled
+
+	c.m.RLock()
+	for _, line := range c.lines[min(first, len(c.lines)):] {
+		line = strings.TrimSpace(ansi.Strip(line))
+		if line == "" {
+			continue
 		}
-		c.m.Unlock()
-		c.updateChan <- ControllerUpdateMsg{}
+		entry.Steps = append(entry.Steps, line)
+		if result != ResultSucceeded && strings.HasPrefix(line, "Error ") {
+			entry.Error = line
+		}
+	}
+	c.m.RUnlock()
+
+	if err := history.Append(c.opts.History, entry); err != nil {
+		log.Printf("Error recording update of %s in history: %v", entry.Container, err)
+	}
+}
+
+// undoStep is the action undoing a step of an update.
+type undoStep struct {
+	line string       // line describes the undo action in the lines.
+	undo func() error // undo undoes the step.
+}
+
+// rollbackSuffix is appended to the name of the old container while the new
+// one is created, so the old one can be restored if the update fails.
+const rollbackSuffix = "-gmd-old"
+
+// composeRollbackRepo is the repository the image of a compose service is
+// tagged in, under the name of its container, before docker compose
+// recreates the container: docker compose removes the old container, the
+// image is tagged back as the service image to recreate it on failure. The
+// tag is kept after a successful update, until the next one, and removed
+// after a rollback.
+const composeRollbackRepo = "gmd-rollback"
+
+// resolveNetworkContainer refers by name to the container whose network
+// stack the given configuration shares, when it may have been recreated by
+// the same batch: its name outlives its ID.
+func (c *Controller) resolveNetworkContainer(hc *container.HostConfig) {
+	if hc == nil || !hc.NetworkMode.IsContainer() {
+		return
+	}
+	if name, ok := c.names[hc.NetworkMode.ConnectedContainer()]; ok {
+		hc.NetworkMode = container.NetworkMode("container:" + name)
+	}
+}
+
+// recreate replaces the given container by a new container created from its
+// inspect output.
+//
+// The old container is stopped and renamed rather than deleted, and only
+// removed once the new container runs and its post-start hooks succeeded.
+// If a step fails, the steps done so far are undone and the old container is
+// restored.
+// It reports whether the container was recreated.
+func (c *Controller) recreate(ctx context.Context, old types.Container, containerName string, containerConfig container.InspectResponse) bool {
+	var undo []undoStep
+	id := old.ID
+
+	err := c.runStep("Stoping container: "+containerName, "stop", func() error {
+		return c.cli.StopContainer(ctx, id)
 	})
+	if err != nil {
+		return false
+	}
+	if containerConfig.State != nil && containerConfig.State.Running {
+		undo = append(undo, undoStep{
+			line: "Starting old container: " + containerName,
+			undo: func() error { return c.cli.StartContainer(ctx, id) },
+		})
+	}
 
+	oldName := containerName + rollbackSuffix
+	err = c.runStep(fmt.Sprintf("Renaming container: %s to %s", containerName, oldName), "rename", func() error {
+		return c.cli.RenameContainer(ctx, id, oldName)
+	})
 	if err != nil {
-		c.m.Lock()
-		c.lines = append(c.lines, fmt.Sprintf("Error stop: %v", err))
-		c.m.Unlock()
-		c.updateChan <- ControllerUpdateMsg{}
-		return
+		c.rollback(containerName, undo)
+		return false
 	}
+	undo = append(undo, undoStep{
+		line: fmt.Sprintf("Renaming container: %s to %s", oldName, containerName),
+		undo: func() error { return c.cli.RenameContainer(ctx, id, containerName) },
+	})
 
-	c.m.Lock()
-	c.lines[len(c.lines)-1] = fmt.Sprintf("%s Stoping container: %s", style.Success().Render("✓"), containerName)
-	c.m.Unlock()
-	c.updateChan <- ControllerUpdateMsg{}
+	var created container.CreateResponse
+	err = c.runStep("Creating container: "+containerName, "create", func() (err error) {
+		created, err = c.cli.CreateContainerFromConfig(ctx, containerConfig)
+		return err
+	})
+	if err != nil {
+		c.rollback(containerName, undo)
+		return false
+	}
+	undo = append(undo, undoStep{
+		line: "Removing new container: " + containerName,
+		undo: func() error {
+			// the new container may have started before failing.
+			if err := c.cli.StopContainer(ctx, created.ID); err != nil {
+				return err
+			}
+			return c.cli.DeleteContainer(ctx, created.ID)
+		},
+	})
 
-	add = true
-	err = spinUntilDone(func() error {
-		return c.cli.DeleteContainer(container.ID)
-	}, func(frame string) {
-		c.m.Lock()
-		if add {
-			c.lines = append(c.lines, fmt.Sprintf("%s Removing container: %s", frame, containerName))
-			add = false
+	err = c.runStep("Starting container: "+containerName, "start", func() error {
+		return c.cli.StartContainer(ctx, created.ID)
+	})
+	if err != nil {
+		c.rollback(containerName, undo)
+		return false
+	}
+
+	err = c.runStep("Waiting for container to be healthy: "+containerName, "health", func() error {
+		return c.cli.WaitHealthy(ctx, created.ID)
+	})
+	if err == nil {
+		err = c.runHooks(ctx, StagePostStart, old, created.ID)
+	}
+	if err != nil {
+		if c.askRollback() {
+			c.rollback(containerName, undo)
 		} else {
-			c.lines[len(c.lines)-1] = fmt.Sprintf("%s Removing container: %s", frame, containerName)
+			c.appendLine(style.Warning().Render("Keeping the new container, the old one is kept as " + oldName))
 		}
-		c.m.Unlock()
-		c.updateChan <- ControllerUpdateMsg{}
+		return false
+	}
+
+	// the new container runs, the update succeeded even if the old container
+	// cannot be removed: it is then left renamed.
+	_ = c.runStep("Removing old container: "+oldName, "remove", func() error {
+		return c.cli.DeleteContainer(ctx, id)
 	})
+	return true
+}
 
-	if err != nil {
-		c.m.Lock()
-		c.lines = append(c.lines, fmt.Sprintf("Error remove: %v", err))
-		c.m.Unlock()
-		c.updateChan <- ControllerUpdateMsg{}
-		return
+// askRollback asks whether to roll back an update whose new container is
+// unhealthy, and waits for Rollback or Keep to be called. Canceling the
+// update keeps the new container.
+func (c *Controller) askRollback() bool {
+	if c.autoRollback {
+		return true
 	}
 
 	c.m.Lock()
-	c.lines[len(c.lines)-1] = fmt.Sprintf("%s Removing container: %s", style.Success().Render("✓"), containerName)
+	c.asking = true
+	c.lines = append(c.lines, style.Warning().Render("Update failed: press r to roll back to the previous image, k to keep the new container"))
 	c.m.Unlock()
-	c.updateChan <- ControllerUpdateMsg{}
+	c.notify()
 
-	add = true
-	cr := spinUntilDone(func() createResponse {
-		r, e := c.cli.CreateContainerFromConfig(containerConfig)
-		return createResponse{Resp: r, Err: e}
-	}, func(frame string) {
+	defer func() {
 		c.m.Lock()
-		if add {
-			c.lines = append(c.lines, fmt.Sprintf("%s Creating container: %s", frame, containerName))
-			add = false
+		c.asking = false
+		c.m.Unlock()
+	}()
+
+	select {
+	case rollback := <-c.decision:
+		return rollback
+	case <-c.done:
+		return false
+	}
+}
+
+// AskingRollback reports whether the controller waits for Rollback or Keep
+// to be called.
+func (c *Controller) AskingRollback() bool {
+	c.m.RLock()
+	defer c.m.RUnlock()
+	return c.asking
+}
+
+// Rollback answers the pending question with a roll back to the old
+// container. It does nothing if no question is pending.
+func (c *Controller) Rollback() {
+	c.answer(true)
+}
+
+// Keep answers the pending question by keeping the new container.
+// It does nothing if no question is pending.
+func (c *Controller) Keep() {
+	c.answer(false)
+}
+
+func (c *Controller) answer(rollback bool) {
+	select {
+	case c.decision <- rollback:
+	default:
+	}
+}
+
+// rollback runs the given undo steps in reverse order to restore the given
+// container. Every step is run even if a previous one failed.
+func (c *Controller) rollback(containerName string, undo []undoStep) {
+	if len(undo) == 0 {
+		return
+	}
+
+	c.appendLine(style.Warning().Render("Rolling back container: " + containerName))
+
+	failed := false
+	for i := len(undo) - 1; i >= 0; i-- {
+		if err := c.runStep(undo[i].line, "rollback", undo[i].undo); err != nil {
+			failed = true
+		}
+	}
+
+	if failed {
+		c.appendLine(style.Danger().Render("Rollback failed, container " + containerName + " may need manual repair"))
+		return
+	}
+	c.rolledBack = true
+	c.appendLine(style.Success().Render("Container " + containerName + " restored"))
+}
+
+// recreateWithCompose recreates the given compose service with docker
+// compose, which keeps the semantics of the compose file the container
+// comes from (service network modes, dependencies, ...) that the inspect
+// output of the container loses.
+// The output of docker compose is appended to the lines. The image the
+// container ran is tagged beforehand, so that the service can be recreated
+// with it if the update fails.
+// It reports whether the service was recreated.
+func (c *Controller) recreateWithCompose(ctx context.Context, old types.Container, containerName string, config container.InspectResponse, service client.ComposeService) bool {
+	id := old.ID
+	wasRunning := config.State != nil && config.State.Running
+	// the service image, config.Image is the ID of the image the container runs.
+	ref := config.Config.Image
+	rollbackRef := composeRollbackRepo + ":" + containerName
+
+	err := c.runStep(fmt.Sprintf("Tagging previous image: %s as %s", ref, rollbackRef), "tag", func() error {
+		return c.cli.TagImage(ctx, config.Image, rollbackRef)
+	})
+	if err != nil {
+		return false
+	}
+
+	if err := c.composeUp(ctx, service, "compose", false); err != nil {
+		c.rollbackCompose(ctx, id, containerName, wasRunning, service, ref, rollbackRef)
+		return false
+	}
+
+	// docker compose keeps the name of the container it recreates.
+	err = c.runStep("Waiting for container to be healthy: "+containerName, "health", func() error {
+		return c.cli.WaitHealthy(ctx, containerName)
+	})
+	if err == nil {
+		err = c.runHooks(ctx, StagePostStart, old, containerName)
+	}
+	if err != nil {
+		if c.askRollback() {
+			c.rollbackComposeImage(ctx, containerName, service, ref, rollbackRef)
 		} else {
-			c.lines[len(c.lines)-1] = fmt.Sprintf("%s Creating container: %s", frame, containerName)
+			c.appendLine(style.Warning().Render("Keeping the new container, the previous image is kept as " + rollbackRef))
 		}
-		c.m.Unlock()
-		c.updateChan <- ControllerUpdateMsg{}
+		return false
+	}
+	return true
+}
+
+// composeUp runs docker compose up for the given service as a step with
+// the given name, forcing the recreation of its container if force is true.
+// The output of docker compose is appended to the lines.
+func (c *Controller) composeUp(ctx context.Context, service client.ComposeService, name string, force bool) error {
+	up := c.cli.ComposeUp
+	if force {
+		up = c.cli.ComposeRecreate
+	}
+	var out []byte
+	err := c.runStep(fmt.Sprintf("Recreating service %s with docker compose", service.Service), name, func() (err error) {
+		out, err = up(ctx, service)
+		return err
 	})
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			c.appendLine(style.Subtitle().Render("  " + line))
+		}
+	}
+	return err
+}
 
-	err = cr.Err
+// rollbackCompose restores the given container after docker compose failed
+// to recreate it. If docker compose failed before removing the old
+// container, the old container is restarted if it was running. Otherwise
+// the service is recreated with the previous image, see
+// rollbackComposeImage.
+func (c *Controller) rollbackCompose(ctx context.Context, id, containerName string, wasRunning bool, service client.ComposeService, ref, rollbackRef string) {
+	old, err := c.cli.ContainerInspect(ctx, id)
+	if err != nil {
+		c.rollbackComposeImage(ctx, containerName, service, ref, rollbackRef)
+		return
+	}
+	if !wasRunning {
+		return
+	}
+
+	c.appendLine(style.Warning().Render("Rolling back container: " + containerName))
 
+	if old.State != nil && old.State.Running {
+		c.rolledBack = true
+		c.appendLine(style.Success().Render("Container " + containerName + " restored"))
+		return
+	}
+
+	err = c.runStep("Starting old container: "+containerName, "rollback", func() error {
+		return c.cli.StartContainer(ctx, id)
+	})
 	if err != nil {
-		c.m.Lock()
-		c.lines = append(c.lines, fmt.Sprintf("Error create: %v", err))
-		c.m.Unlock()
-		c.updateChan <- ControllerUpdateMsg{}
+		c.appendLine(style.Danger().Render("Rollback failed, container " + containerName + " may need manual repair"))
 		return
 	}
+	c.rolledBack = true
+	c.appendLine(style.Success().Render("Container " + containerName + " restored"))
+}
+
+// rollbackComposeImage restores the given compose service once docker
+// compose removed its old container: the previous image, tagged as
+// rollbackRef, is tagged back as the service image ref, and the service is
+// recreated with it.
+func (c *Controller) rollbackComposeImage(ctx context.Context, containerName string, service client.ComposeService, ref, rollbackRef string) {
+	c.appendLine(style.Warning().Render("Rolling back container: " + containerName))
 
+	err := c.runStep(fmt.Sprintf("Tagging previous image: %s as %s", rollbackRef, ref), "rollback", func() error {
+		return c.cli.TagImage(ctx, rollbackRef, ref)
+	})
+	if err == nil {
+		err = c.composeUp(ctx, service, "rollback", true)
+	}
+	if err == nil {
+		// ref refers to the previous image again, the image is not removed.
+		_ = c.cli.UntagImage(ctx, rollbackRef)
+	}
+	if err != nil {
+		c.appendLine(style.Danger().Render("Rollback failed, container " + containerName + " may need manual repair"))
+		return
+	}
+	c.rolledBack = true
+	c.appendLine(style.Success().Render("Container " + containerName + " restored"))
+}
+
+// appendLine appends the given line to the lines and notifies the model.
+func (c *Controller) appendLine(line string) {
 	c.m.Lock()
-	c.lines[len(c.lines)-1] = fmt.Sprintf("%s Creating container: %s", style.Success().Render("✓"), containerName)
+	c.lines = append(c.lines, line)
 	c.m.Unlock()
-	c.updateChan <- ControllerUpdateMsg{}
+	c.notify()
+}
 
-	add = true
-	err = spinUntilDone(func() error {
-		return c.cli.StartContainer(cr.Resp.ID)
-	}, func(frame string) {
+// runStep runs action while a spinner is shown in front of the given line.
+// The line is marked done once action succeeds. Otherwise the error of
+// action is appended to the lines, after the given step name, and returned.
+func (c *Controller) runStep(line, name string, action func() error) error {
+	// the line is the last one until action returns, hooks write their
+	// output above it.
+	c.appendLine(fmt.Sprintf("%s %s", style.Spinner().Render(spinnerFrames[0]), line))
+
+	err := spinUntilDone(action, func(frame string) {
 		c.m.Lock()
-		if add {
-			c.lines = append(c.lines, fmt.Sprintf("%s Starting container: %s", frame, containerName))
-			add = false
-		} else {
-			c.lines[len(c.lines)-1] = fmt.Sprintf("%s Starting container: %s", frame, containerName)
-		}
+		c.lines[len(c.lines)-1] = fmt.Sprintf("%s %s", frame, line)
 		c.m.Unlock()
-		c.updateChan <- ControllerUpdateMsg{}
+		c.notify()
 	})
 
 	if err != nil {
-		c.m.Lock()
-		c.lines = append(c.lines, fmt.Sprintf("Error start: %v", err))
-		c.m.Unlock()
-		c.updateChan <- ControllerUpdateMsg{}
-		return
+		c.appendLine(fmt.Sprintf("Error %s: %v", name, err))
+		return err
 	}
 
 	c.m.Lock()
-	c.lines[len(c.lines)-1] = fmt.Sprintf("%s Starting container: %s", style.Success().Render("✓"), containerName)
+	c.lines[len(c.lines)-1] = fmt.Sprintf("%s %s", style.Success().Render("✓"), line)
 	c.m.Unlock()
-	c.updateChan <- ControllerUpdateMsg{}
-
-	c.m.Lock()
-	c.lines = append(c.lines, "update complete, press enter to close...")
-	c.m.Unlock()
-
-	close(c.updateChan)
+	c.notify()
+	return nil
 }
 
+// spinnerFrames are the frames of the spinner shown in front of running steps.
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
 func spinUntilDone[T any](
 	action func() T,
 	updateLine func(frame string),
@@ -254,7 +700,6 @@ func spinUntilDone[T any](
 		done <- action()
 	}()
 
-	spinner := []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
 	index := 0
 
 	for {
@@ -265,8 +710,8 @@ func spinUntilDone[T any](
 
 		case <-time.After(100 * time.Millisecond):
 			// Frame suivante
-			frame := spinner[index]
-			index = (index + 1) % len(spinner)
+			frame := spinnerFrames[index]
+			index = (index + 1) % len(spinnerFrames)
 
 			updateLine(style.Spinner().Render(frame))
 		}
@@ -0,0 +1,187 @@
+package containerupdate
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kdruelle/gmd/config"
+	"github.com/kdruelle/gmd/docker/types"
+	style "github.com/kdruelle/gmd/tui/styles"
+)
+
+// Stage is a point of an update where the hooks of the container run.
+type Stage string
+
+const (
+	StagePrePull   Stage = "pre-pull"   // StagePrePull runs before the image is pulled.
+	StagePreStop   Stage = "pre-stop"   // StagePreStop runs before the old container is stopped.
+	StagePostStart Stage = "post-start" // StagePostStart runs once the new container is started and healthy.
+	StageOnFailure Stage = "on-failure" // StageOnFailure runs once an update failed, after the rollback.
+)
+
+// hookLabelPrefix prefixes the labels defining the hooks of a container:
+// gmd.hooks.<stage> is run inside the container, gmd.hooks.<stage>.host on
+// the host running gmd, for the containers of Options.HostHookLabels only.
+const hookLabelPrefix = "gmd.hooks."
+
+// hookTimeout bounds the duration of a hook.
+const hookTimeout = 30 * time.Minute
+
+// hookOf returns the hook of the given stage of the given container. The
+// labels of the container take precedence over the hooks of the options.
+// A label only defines the host command of a container allowed by
+// Options.HostHookLabels: labels come from the image and from whoever can
+// create containers on the daemon, they must not run code on this host.
+func hookOf(opts Options, container types.Container, stage Stage) config.Hook {
+	containerName := strings.TrimPrefix(container.Name, "/")
+	hook := opts.Hooks[containerName][string(stage)]
+	if cmd := container.Label(hookLabelPrefix + string(stage)); cmd != "" {
+		hook.Exec = cmd
+	}
+	if cmd := container.Label(hookLabelPrefix + string(stage) + ".host"); cmd != "" {
+		if slices.Contains(opts.HostHookLabels, containerName) || slices.Contains(opts.HostHookLabels, container.Host+"/"+containerName) {
+			hook.Host = cmd
+		} else {
+			log.Printf("ignoring host hook label of container %s: not in host-hook-labels", containerName)
+		}
+	}
+	return hook
+}
+
+// hookSteps returns the steps running the hook of the given stage of the
+// given container, as shown by a plan.
+func hookSteps(opts Options, container types.Container, stage Stage) []string {
+	hook := hookOf(opts, container, stage)
+	containerName := strings.TrimPrefix(container.Name, "/")
+
+	var steps []string
+	if hook.Exec != "" {
+		steps = append(steps, fmt.Sprintf("Running %s hook in container: %s (%s)", stage, containerName, hook.Exec))
+	}
+	if hook.Host != "" {
+		steps = append(steps, fmt.Sprintf("Running %s hook on host for: %s (%s)", stage, containerName, hook.Host))
+	}
+	return steps
+}
+
+// runHooks runs the hook of the given stage of the given container, the
+// command inside the container first, then the host command. The command
+// inside the container runs in the container with the given ID, and is
+// skipped if it does not run. The output of the commands is appended to the
+// lines.
+// It returns an error if a command could not be run or exited with a
+// non-zero code, in which case the remaining command is not run.
+func (c *Controller) runHooks(ctx context.Context, stage Stage, container types.Container, id string) error {
+	hook := hookOf(c.opts, container, stage)
+	containerName := strings.TrimPrefix(container.Name, "/")
+
+	ctx, cancel := context.WithTimeout(ctx, hookTimeout)
+	defer cancel()
+
+	if hook.Exec != "" {
+		if inspect, err := c.cli.ContainerInspect(ctx, id); err != nil || inspect.State == nil || !inspect.State.Running {
+			c.appendLine(style.Inactive().Render(fmt.Sprintf("Skipping %s hook in container %s: not running", stage, containerName)))
+		} else {
+			output := c.hookOutput()
+			err := c.runStep(fmt.Sprintf("Running %s hook in container: %s", stage, containerName), "hook", func() error {
+				code, err := c.cli.Exec(ctx, id, []string{"sh", "-c", hook.Exec}, output)
+				output.flush()
+				if err != nil {
+					return err
+				}
+				if code != 0 {
+					return fmt.Errorf("%s hook exited with code %d", stage, code)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if hook.Host != "" {
+		output := c.hookOutput()
+		err := c.runStep(fmt.Sprintf("Running %s hook on host for: %s", stage, containerName), "hook", func() error {
+			cmd := exec.CommandContext(ctx, "sh", "-c", hook.Host)
+			cmd.Env = append(os.Environ(),
+				"GMD_STAGE="+string(stage),
+				"GMD_HOST="+container.Host,
+				"GMD_CONTAINER="+containerName,
+				"GMD_CONTAINER_ID="+id,
+				"GMD_IMAGE="+container.Config.Image,
+			)
+			cmd.Stdout = output
+			cmd.Stderr = output
+			err := cmd.Run()
+			output.flush()
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// hookOutput returns a writer appending the lines written to it to the
+// lines of the controller.
+func (c *Controller) hookOutput() *lineWriter {
+	return &lineWriter{line: func(line string) {
+		c.m.Lock()
+		// the last line is the spinner of the running hook, the output goes
+		// above it.
+		last := len(c.lines) - 1
+		out := style.Subtitle().Render("  │ " + line)
+		if last >= 0 {
+			c.lines = append(c.lines[:last], out, c.lines[last])
+		} else {
+			c.lines = append(c.lines, out)
+		}
+		c.m.Unlock()
+		c.notify()
+	}}
+}
+
+// lineWriter is a writer calling line for every line written to it.
+type lineWriter struct {
+	mu   sync.Mutex
+	buf  bytes.Buffer
+	line func(string)
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf.Write(p)
+	for {
+		i := bytes.IndexByte(w.buf.Bytes(), '\n')
+		if i < 0 {
+			break
+		}
+		line := strings.TrimRight(string(w.buf.Next(i+1)), "\r\n")
+		w.line(line)
+	}
+	return len(p), nil
+}
+
+// flush passes the last unterminated line to line.
+func (w *lineWriter) flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.buf.Len() > 0 {
+		w.line(strings.TrimRight(w.buf.String(), "\r\n"))
+		w.buf.Reset()
+	}
+}
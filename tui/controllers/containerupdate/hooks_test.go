@@ -0,0 +1,62 @@
+package containerupdate
+
+import (
+	"testing"
+
+	"github.com/kdruelle/gmd/config"
+)
+
+func TestHookOfHostLabels(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed []string     // allowed are the containers whose host hook labels are honoured.
+		hooks   config.Hooks // hooks are the configured hooks of the container.
+		want    config.Hook
+	}{
+		{
+			name: "not listed",
+			want: config.Hook{Exec: "nginx -s quit"},
+		},
+		{
+			name:    "other container listed",
+			allowed: []string{"db", "local/db"},
+			want:    config.Hook{Exec: "nginx -s quit"},
+		},
+		{
+			name:    "listed on another host",
+			allowed: []string{"remote/web"},
+			want:    config.Hook{Exec: "nginx -s quit"},
+		},
+		{
+			name:  "configured host hook kept",
+			hooks: config.Hooks{string(StagePreStop): {Host: "backup.sh"}},
+			want:  config.Hook{Exec: "nginx -s quit", Host: "backup.sh"},
+		},
+		{
+			name:    "listed by name",
+			allowed: []string{"web"},
+			want:    config.Hook{Exec: "nginx -s quit", Host: "rm -rf /srv/cache"},
+		},
+		{
+			name:    "listed by host and name",
+			allowed: []string{"local/web"},
+			hooks:   config.Hooks{string(StagePreStop): {Host: "backup.sh"}},
+			want:    config.Hook{Exec: "nginx -s quit", Host: "rm -rf /srv/cache"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := testContainer("local", "web", "w1", "bridge")
+			c.Config.Labels[hookLabelPrefix+string(StagePreStop)] = "nginx -s quit"
+			c.Config.Labels[hookLabelPrefix+string(StagePreStop)+".host"] = "rm -rf /srv/cache"
+
+			opts := Options{
+				Hooks:          map[string]config.Hooks{"web": tt.hooks},
+				HostHookLabels: tt.allowed,
+			}
+			if got := hookOf(opts, c, StagePreStop); got != tt.want {
+				t.Errorf("hookOf = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
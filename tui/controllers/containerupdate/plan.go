@@ -0,0 +1,88 @@
+package containerupdate
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/kdruelle/gmd/docker/client"
+	"github.com/kdruelle/gmd/docker/types"
+)
+
+// Plan describes what updating a container would do, without doing it.
+type Plan struct {
+	Container string                 // Container is the name of the container.
+	Compose   *client.ComposeService // Compose is the service recreating the container, nil if it is recreated from its inspect.
+	Steps     []string               // Steps are the steps of the update, in order.
+	Current   client.ImageInfo       // Current is the image the container runs.
+	Target    client.ImageInfo       // Target is the image the container would be updated to.
+	TargetErr error                  // TargetErr is the error met looking up Target in the registry.
+	Changes   []client.ConfigChange  // Changes are the differences between the container and the configuration it would be created with.
+}
+
+// NewPlan returns the plan of the update of the given container, as a
+// controller with the given options would run it.
+// It returns an error if the container or its image could not be inspected.
+// The registry being unreachable is reported in the TargetErr field.
+func NewPlan(ctx context.Context, cli *client.Client, container types.Container, opts Options) (Plan, error) {
+	containerName := strings.TrimPrefix(container.Name, "/")
+	p := Plan{Container: containerName}
+
+	containerConfig, err := cli.ContainerInspect(ctx, container.ID)
+	if err != nil {
+		return Plan{}, err
+	}
+	ref := container.UpdateImage()
+	tracking := ref != containerConfig.Config.Image
+
+	p.Current, err = cli.LocalImage(ctx, containerConfig.Image, containerConfig.Config.Image)
+	if err != nil {
+		return Plan{}, err
+	}
+	p.Target, p.TargetErr = cli.RemoteImage(ctx, ref)
+
+	p.Steps = append(p.Steps, hookSteps(opts, container, StagePrePull)...)
+	p.Steps = append(p.Steps, "Pulling image: "+ref)
+	p.Steps = append(p.Steps, hookSteps(opts, container, StagePreStop)...)
+
+	health := cli.Timeouts().Health
+	if service, ok := composeService(ctx, cli, opts, containerConfig, tracking); ok {
+		p.Compose = &service
+		p.Steps = append(p.Steps, fmt.Sprintf("Tagging previous image: %s as %s:%s", containerConfig.Config.Image, composeRollbackRepo, containerName))
+		p.Steps = append(p.Steps, fmt.Sprintf("Recreating service %s with: docker %s", service.Service, strings.Join(cli.ComposeArgs(service), " ")))
+		if health > 0 {
+			p.Steps = append(p.Steps, fmt.Sprintf("Waiting for container to be healthy: %s (up to %s)", containerName, health))
+		}
+		p.Steps = append(p.Steps, hookSteps(opts, container, StagePostStart)...)
+		return p, nil
+	}
+
+	// the container is recreated with the image it is updated to.
+	update, cfg := containerConfig, *containerConfig.Config
+	cfg.Image = ref
+	update.Config = &cfg
+
+	create, err := cli.CreateConfig(ctx, update)
+	if err != nil {
+		return Plan{}, err
+	}
+	p.Changes = client.DiffConfig(containerConfig, create)
+
+	oldName := containerName + rollbackSuffix
+	p.Steps = append(p.Steps,
+		"Stoping container: "+containerName,
+		fmt.Sprintf("Renaming container: %s to %s", containerName, oldName),
+		"Creating container: "+containerName,
+	)
+	for _, name := range client.SecondaryNetworks(create) {
+		p.Steps = append(p.Steps, "Connecting network: "+name)
+	}
+	p.Steps = append(p.Steps, "Starting container: "+containerName)
+	if health > 0 {
+		p.Steps = append(p.Steps, fmt.Sprintf("Waiting for container to be healthy: %s (up to %s)", containerName, health))
+	}
+	p.Steps = append(p.Steps, hookSteps(opts, container, StagePostStart)...)
+	p.Steps = append(p.Steps, "Removing old container: "+oldName)
+
+	return p, nil
+}
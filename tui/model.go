@@ -1,12 +1,17 @@
 package tui
 
 import (
+	"log"
+	"slices"
+
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/kdruelle/gmd/docker/cache"
 	"github.com/kdruelle/gmd/docker/client"
 	"github.com/kdruelle/gmd/tui/commands"
 	"github.com/kdruelle/gmd/tui/componants"
+	"github.com/kdruelle/gmd/tui/controllers/containerupdate"
 	"github.com/kdruelle/gmd/tui/models/containers"
+	"github.com/kdruelle/gmd/tui/models/loaderror"
 	"github.com/kdruelle/gmd/tui/models/maintab"
 )
 
@@ -15,26 +20,32 @@ import (
 // ---------------------------------------------------
 
 type Model struct {
-	cli         *client.Client
-	dockerCache *cache.Cache
+	dockerCache *cache.Group
 	stack       []tea.Model
+	updateOpts  containerupdate.Options
 
 	screeWidth   int
 	screenHeight int
 }
 
-func NewModel() (Model, error) {
-	cli, err := client.NewClient()
+func NewModel(opts client.Options, updateOpts containerupdate.Options) (Model, error) {
+	clis, err := client.NewClients(opts)
 	if err != nil {
 		return Model{}, err
 	}
-	cache := cache.NewCache(cli)
+	cache, err := cache.NewGroup(clis...)
+	if err != nil {
+		for _, cli := range clis {
+			cli.Close()
+		}
+		return Model{}, err
+	}
 
-	mainModel := maintab.New(cli, cache)
+	mainModel := maintab.New(cache, updateOpts)
 
 	m := Model{
-		cli:         cli,
 		dockerCache: cache,
+		updateOpts:  updateOpts,
 	}
 
 	m.stack = []tea.Model{
@@ -48,7 +59,7 @@ func (m Model) Init() tea.Cmd {
 	top := m.stack[len(m.stack)-1]
 	return tea.Batch(
 		StartMonitorCache(m.dockerCache),
-		WaitDockerEvent(m.dockerCache.Events()),
+		WaitDockerEvent(m.dockerCache),
 		top.Init(),
 	)
 }
@@ -76,10 +87,32 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, tea.Quit
 		}
 
-	case cache.Event:
+	case dockerEventMsg:
+		if msg.cache != m.dockerCache {
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.stack[0], cmd = m.stack[0].Update(msg.event)
+		return m, tea.Batch(WaitDockerEvent(m.dockerCache), cmd)
+
+	case commands.ChangeMsg:
 		var cmd tea.Cmd
 		m.stack[0], cmd = m.stack[0].Update(msg)
-		return m, tea.Batch(WaitDockerEvent(m.dockerCache.Events()), cmd)
+		return m, cmd
+
+	case CacheStartMsg:
+		if msg.cache != m.dockerCache || msg.Err == nil {
+			return m, nil
+		}
+		return m, commands.SwitchPageCmd(func() tea.Model {
+			return loaderror.New(msg.Err)
+		})
+
+	case commands.SwitchClientMsg:
+		return m, m.switchClients(msg.Client)
+
+	case commands.ReloadMsg:
+		return m, m.switchClients(m.dockerCache.Clients()...)
 
 	case containers.ContainerUpdateMsg:
 		var cmd tea.Cmd
@@ -89,6 +122,9 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case commands.SwitchPageMsg:
 		model := msg.Model
 		if model == nil {
+			if closable, ok := m.stack[len(m.stack)-1].(componants.Closable); ok {
+				closable.Close()
+			}
 			m.stack = m.stack[:len(m.stack)-1] // pop
 			return m, nil
 		}
@@ -104,6 +140,53 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+// switchClients tears down the current caches and their event streams, then
+// rebuilds the main screen on top of the given clients. The current clients
+// that are not reused are closed. If the given clients cannot be grouped, the
+// new ones are closed and the current screen is kept under the error.
+func (m *Model) switchClients(clis ...*client.Client) tea.Cmd {
+	group, err := cache.NewGroup(clis...)
+	if err != nil {
+		current := m.dockerCache.Clients()
+		for _, cli := range clis {
+			if !slices.Contains(current, cli) {
+				cli.Close()
+			}
+		}
+		return commands.SwitchPageCmd(func() tea.Model {
+			return loaderror.New(err)
+		})
+	}
+
+	for _, model := range m.stack {
+		if closable, ok := model.(componants.Closable); ok {
+			closable.Close()
+		}
+	}
+	m.dockerCache.Close()
+	for _, cli := range m.dockerCache.Clients() {
+		if !slices.Contains(clis, cli) {
+			if err := cli.Close(); err != nil {
+				log.Printf("close client %s: %v", cli.Endpoint().Name, err)
+			}
+		}
+	}
+
+	m.dockerCache = group
+
+	mainModel := maintab.New(m.dockerCache, m.updateOpts)
+	m.stack = []tea.Model{
+		mainModel,
+	}
+
+	return tea.Batch(
+		StartMonitorCache(m.dockerCache),
+		WaitDockerEvent(m.dockerCache),
+		mainModel.Init(),
+		SendResize(m.screeWidth, m.screenHeight),
+	)
+}
+
 // ---------------------------------------------------
 // View
 // ---------------------------------------------------
@@ -0,0 +1,29 @@
+package batchupdate
+
+import (
+	"context"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kdruelle/gmd/tui/controllers/containerupdate"
+)
+
+// BatchFinishedMsg tells the model every update of the batch ended.
+type BatchFinishedMsg struct {
+}
+
+func startBatch(ctx context.Context, b *containerupdate.Batch) tea.Cmd {
+	return func() tea.Msg {
+		b.Start(ctx)
+		return containerupdate.ControllerUpdateMsg{}
+	}
+}
+
+func waitBatchEvent(updatech <-chan containerupdate.ControllerUpdateMsg) tea.Cmd {
+	return func() tea.Msg {
+		msg, ok := <-updatech
+		if !ok {
+			return BatchFinishedMsg{}
+		}
+		return msg
+	}
+}
@@ -0,0 +1,190 @@
+// Package batchupdate provides the screen following the update of several
+// containers at once, and summing up the updates once they all ended.
+package batchupdate
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/kdruelle/gmd/docker/client"
+	"github.com/kdruelle/gmd/docker/types"
+	"github.com/kdruelle/gmd/tui/commands"
+	"github.com/kdruelle/gmd/tui/controllers/containerupdate"
+	style "github.com/kdruelle/gmd/tui/styles"
+)
+
+type Model struct {
+	ctx       context.Context
+	cancel    context.CancelFunc
+	batch     *containerupdate.Batch
+	multiHost bool
+	screenW   int
+	screenH   int
+	completed bool
+}
+
+type listKeyMap struct {
+	returnKey key.Binding
+	cancelKey key.Binding
+}
+
+var keyMap = &listKeyMap{
+	returnKey: key.NewBinding(
+		key.WithKeys("esc", "enter"),
+		key.WithHelp("enter", "get back to main menu"),
+	),
+	cancelKey: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "cancel the pending updates and get back to main menu"),
+	),
+}
+
+// New returns a screen updating the given containers with the given options.
+// clients returns the client of the daemon of a host.
+func New(containers []types.Container, clients func(host string) *client.Client, multiHost bool, opts containerupdate.Options) Model {
+	ctx, cancel := context.WithCancel(context.Background())
+	return Model{
+		ctx:       ctx,
+		cancel:    cancel,
+		batch:     containerupdate.NewBatch(containers, clients, opts),
+		multiHost: multiHost,
+	}
+}
+
+func (m Model) Init() tea.Cmd {
+	return startBatch(m.ctx, m.batch)
+}
+
+// Close cancels the updates that did not start yet.
+func (m Model) Close() {
+	m.cancel()
+}
+
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.screenW = msg.Width
+		m.screenH = msg.Height
+	case containerupdate.ControllerUpdateMsg:
+		return m, waitBatchEvent(m.batch.Events())
+	case BatchFinishedMsg:
+		m.completed = true
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, keyMap.returnKey) && m.completed:
+			return m, commands.SwitchPageCmd(nil)
+		case key.Matches(msg, keyMap.cancelKey):
+			return m, commands.SwitchPageCmd(nil)
+		}
+	}
+	return m, nil
+}
+
+func (m Model) View() string {
+	jobs := m.batch.Status()
+
+	title := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("#88C0D0")).
+		Width(110).
+		Align(lipgloss.Center).
+		Render(fmt.Sprintf("Updating %d containers, %d at a time ...", len(jobs), m.batch.Parallel()))
+
+	lines := make([]string, 0, len(jobs)+8)
+	for _, j := range jobs {
+		lines = append(lines, m.jobLine(j))
+	}
+	if m.completed {
+		lines = append(lines, "")
+		lines = append(lines, summary(jobs)...)
+		lines = append(lines, "", "batch complete, press enter to close...")
+	}
+
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		title,
+		"",
+		lipgloss.JoinVertical(lipgloss.Left, lines...),
+	)
+
+	box := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("#81A1C1")).
+		Padding(1, 2).
+		Width(110).
+		Align(lipgloss.Left)
+
+	return lipgloss.Place(
+		m.screenW, m.screenH,
+		lipgloss.Center, lipgloss.Center,
+		box.Render(content),
+	)
+}
+
+// jobLine returns the line of the given update: its state, the container
+// and the last line of its log.
+func (m Model) jobLine(j containerupdate.JobStatus) string {
+	name := j.Name
+	if m.multiHost {
+		name = j.Host + "/" + name
+	}
+	name = lipgloss.NewStyle().Width(32).Render(style.Title().Render(name))
+	return lipgloss.JoinHorizontal(lipgloss.Top, resultFlag(j), " ", name, " ", truncate(j.Line, 60))
+}
+
+// resultFlag returns the flag of the state of the given update.
+func resultFlag(j containerupdate.JobStatus) string {
+	switch j.Result {
+	case containerupdate.ResultSucceeded:
+		return style.Success().Render("✓")
+	case containerupdate.ResultFailed:
+		return style.Danger().Render("✗")
+	case containerupdate.ResultRolledBack:
+		return style.Warning().Render("↺")
+	case containerupdate.ResultCanceled, containerupdate.ResultSkipped:
+		return style.Inactive().Render("-")
+	}
+	if j.Started {
+		return style.Spinner().Render("•")
+	}
+	return style.Inactive().Render("·")
+}
+
+// summary returns the lines summing up the given updates.
+func summary(jobs []containerupdate.JobStatus) []string {
+	var succeeded, failed, rolledBack, skipped []string
+	for _, j := range jobs {
+		switch j.Result {
+		case containerupdate.ResultSucceeded:
+			succeeded = append(succeeded, j.Name)
+		case containerupdate.ResultFailed:
+			failed = append(failed, j.Name)
+		case containerupdate.ResultRolledBack:
+			rolledBack = append(rolledBack, j.Name)
+		default:
+			skipped = append(skipped, j.Name)
+		}
+	}
+
+	lines := []string{style.Bold().Render("Summary")}
+	add := func(render lipgloss.Style, label string, names []string) {
+		if len(names) == 0 {
+			return
+		}
+		lines = append(lines, render.Render(fmt.Sprintf("  %d %s", len(names), label))+" "+style.Subtitle().Render(strings.Join(names, ", ")))
+	}
+	add(style.Success(), "updated", succeeded)
+	add(style.Danger(), "failed", failed)
+	add(style.Warning(), "rolled back", rolledBack)
+	add(style.Inactive(), "skipped", skipped)
+	return lines
+}
+
+// truncate shortens the given line to the given width.
+func truncate(line string, width int) string {
+	return lipgloss.NewStyle().MaxWidth(width).Render(line)
+}
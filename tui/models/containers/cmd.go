@@ -1,6 +1,9 @@
 package containers
 
 import (
+	"context"
+	"time"
+
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/kdruelle/gmd/docker/client"
 	"github.com/kdruelle/gmd/tui/controllers/containerstats"
@@ -13,11 +16,22 @@ type ContainerActionMsg struct {
 }
 
 type ContainerUpdateMsg struct {
+	Host        string
 	ContainerID string
 	Update      bool
 	Err         error
 }
 
+// loadingTickMsg asks the model to render the loading progress again.
+type loadingTickMsg struct{}
+
+// loadingTickCmd returns a command sending a loadingTickMsg shortly.
+func loadingTickCmd() tea.Cmd {
+	return tea.Tick(200*time.Millisecond, func(time.Time) tea.Msg {
+		return loadingTickMsg{}
+	})
+}
+
 // func StartContainerCmd(cli *client.Client, id string) tea.Cmd {
 // 	return func() tea.Msg {
 // 		msg := ContainerActionMsg{ContainerID: id, Action: "start"}
@@ -26,18 +40,18 @@ type ContainerUpdateMsg struct {
 // 	}
 // }
 
-func RestartContainerCmd(cli *client.Client, id string) tea.Cmd {
+func RestartContainerCmd(ctx context.Context, cli *client.Client, id string) tea.Cmd {
 	return func() tea.Msg {
 		msg := ContainerActionMsg{ContainerID: id, Action: "restart"}
-		msg.Err = cli.RestartContainer(id)
+		msg.Err = cli.RestartContainer(ctx, id)
 		return msg
 	}
 }
 
-func CheckContainerUpdate(cli *client.Client, id string) tea.Cmd {
+func CheckContainerUpdate(ctx context.Context, cli *client.Client, id string) tea.Cmd {
 	return func() tea.Msg {
-		update, err := cli.CheckUpdate(id)
-		return ContainerUpdateMsg{ContainerID: id, Update: update, Err: err}
+		update, err := cli.CheckUpdate(ctx, id)
+		return ContainerUpdateMsg{Host: cli.Endpoint().Name, ContainerID: id, Update: update, Err: err}
 	}
 }
 
@@ -26,6 +26,15 @@ func (d ItemDelegate) Update(msg tea.Msg, m *list.Model) tea.Cmd {
 }
 
 func (d ItemDelegate) Render(w io.Writer, m list.Model, index int, item list.Item) {
+	if p, ok := item.(ProjectItem); ok {
+		content := p.Render(index == m.Index())
+		if index == m.Index() {
+			content = style.ListSelectedLine().Inherit(style.Bold()).Render(content)
+		}
+		fmt.Fprint(w, lipgloss.JoinHorizontal(lipgloss.Center, content, " "))
+		return
+	}
+
 	c, ok := item.(ContainerItem)
 	if !ok {
 		return
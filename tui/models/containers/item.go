@@ -11,30 +11,43 @@ import (
 )
 
 type ContainerItem struct {
+	host         string
 	id           string
 	name         string
+	project      string
 	state        container.ContainerState
 	actionState  container.ContainerState
 	update       *bool
+	policy       types.UpdatePolicy // policy is the update policy of the container.
 	content      string
 	statsContent string
 	image        string
 	ip4Address   string
 	ip6Address   string
 
-	show bool
+	show     bool
+	showHost bool
+	selected bool // selected is true when the container is selected for a batch update.
 }
 
-func NewContainerItem(dc types.Container) ContainerItem {
+func NewContainerItem(dc types.Container, showHost bool) ContainerItem {
 	c := ContainerItem{
+		host:       dc.Host,
+		showHost:   showHost,
 		id:         dc.ID,
 		name:       dc.Name,
+		project:    dc.Project(),
 		state:      dc.State.Status,
 		image:      dc.Config.Image,
+		policy:     dc.UpdatePolicy(),
 		ip4Address: "-",
 		ip6Address: "-",
 	}
 
+	if ref := dc.UpdateImage(); ref != dc.Config.Image {
+		c.image += " → " + ref
+	}
+
 	keys := make([]string, 0, len(dc.NetworkSettings.Networks))
 	for k := range dc.NetworkSettings.Networks {
 		keys = append(keys, k)
@@ -72,6 +85,9 @@ func NewContainerItem(dc types.Container) ContainerItem {
 func (c *ContainerItem) RenderContent() {
 
 	title := style.Title().Render(c.Name())
+	if c.selected {
+		title = SelectedFlag + " " + title
+	}
 	shortID := style.Subtitle().Render(c.ShortID())
 
 	// statsContent := "CPU[ -- ]   RAM[ -- ]"
@@ -82,17 +98,23 @@ func (c *ContainerItem) RenderContent() {
 	col3 := lipgloss.JoinHorizontal(lipgloss.Center, " ", style.Subtitle().Render(c.image))
 	col4 := lipgloss.JoinVertical(lipgloss.Left, style.Normal().Render(c.ip4Address), style.Normal().Render(c.ip6Address))
 
-	col1 = colNameStyle.Render(col1)
+	col1 = c.nameStyle().Render(col1)
 	col2 = colStateStyle.Render(col2)
 	col3 = colImageStyle.Render(col3)
 	col4 = colAddressStyle.Render(col4)
 
 	c.content = lipgloss.JoinHorizontal(lipgloss.Center, col1, " ", col2, " ", col3, " ", col4)
+	if c.showHost {
+		c.content = lipgloss.JoinHorizontal(lipgloss.Center, colHostStyle.Render(style.Subtitle().Render(c.host)), c.content)
+	}
 }
 
 func (c *ContainerItem) Render(selected bool) string {
 
 	title := style.Title().Render(c.Name())
+	if c.selected {
+		title = SelectedFlag + " " + title
+	}
 	shortID := style.Subtitle().Render(c.ShortID())
 
 	col1 := lipgloss.JoinVertical(lipgloss.Left, title, shortID)
@@ -100,7 +122,7 @@ func (c *ContainerItem) Render(selected bool) string {
 	col3 := lipgloss.JoinHorizontal(lipgloss.Center, " ", style.Subtitle().Render(c.image))
 	col4 := lipgloss.JoinVertical(lipgloss.Left, style.Normal().Render(c.ip4Address), style.Normal().Render(c.ip6Address))
 
-	col1 = colNameStyle.Render(col1)
+	col1 = c.nameStyle().Render(col1)
 	col2 = colStateStyle.Render(col2)
 	col3 = colImageStyle.Render(col3)
 	col4 = colAddressStyle.Render(col4)
@@ -112,6 +134,14 @@ func (c *ContainerItem) Render(selected bool) string {
 		col4 = style.Bold().Render(col4)
 	}
 
+	if c.showHost {
+		col0 := colHostStyle.Render(style.Subtitle().Render(c.host))
+		if selected {
+			col0 = style.Bold().Render(col0)
+		}
+		return lipgloss.JoinHorizontal(lipgloss.Center, col0, col1, " ", col2, " ", col3, " ", col4)
+	}
+
 	return lipgloss.JoinHorizontal(lipgloss.Center, col1, " ", col2, " ", col3, " ", col4)
 }
 
@@ -134,6 +164,25 @@ func (c *ContainerItem) Render(selected bool) string {
 // 	)
 // }
 
+// nameStyle returns the style of the name column, indented for the members
+// of a compose project.
+func (c ContainerItem) nameStyle() lipgloss.Style {
+	if c.project != "" {
+		return colMemberNameStyle
+	}
+	return colNameStyle
+}
+
+// key returns the key of the container.
+func (c ContainerItem) key() containerKey {
+	return containerKey{host: c.host, id: c.id}
+}
+
+// projectKey returns the key of the compose project of the container.
+func (c ContainerItem) projectKey() projectKey {
+	return projectKey{host: c.host, name: c.project}
+}
+
 func (c ContainerItem) Name() string {
 	title := strings.TrimPrefix(c.name, "/")
 	return title
@@ -174,6 +223,18 @@ func (c ContainerItem) Status() string {
 }
 
 func (c ContainerItem) UpdateFlag() string {
+	switch c.policy {
+	case types.UpdatePolicySkip:
+		return PolicySkipFlag
+	case types.UpdatePolicyAuto:
+		return c.updateState() + PolicyAutoFlag
+	default:
+		return c.updateState()
+	}
+}
+
+// updateState returns the flag telling whether the container is outdated.
+func (c ContainerItem) updateState() string {
 	if c.update == nil {
 		return UpdateUnavailable
 	}
@@ -1,7 +1,8 @@
 package containers
 
 import (
-	"errors"
+	"context"
+	"fmt"
 	"log"
 	"os/exec"
 	"slices"
@@ -13,23 +14,36 @@ import (
 	"github.com/charmbracelet/lipgloss"
 	"github.com/docker/docker/api/types/container"
 	"github.com/kdruelle/gmd/docker/cache"
-	"github.com/kdruelle/gmd/docker/client"
 	"github.com/kdruelle/gmd/docker/types"
 	"github.com/kdruelle/gmd/tui/commands"
 	"github.com/kdruelle/gmd/tui/controllers/containerstats"
+	ctrlupdate "github.com/kdruelle/gmd/tui/controllers/containerupdate"
+	"github.com/kdruelle/gmd/tui/models/batchupdate"
 	"github.com/kdruelle/gmd/tui/models/containerupdate"
+	"github.com/kdruelle/gmd/tui/models/updateplan"
 	style "github.com/kdruelle/gmd/tui/styles"
 )
 
 type Model struct {
-	cli                   *client.Client
-	cache                 *cache.Cache
+	ctx                   context.Context
+	cancel                context.CancelFunc
+	cache                 *cache.Group
 	list                  list.Model
 	loaded                bool
 	status                string
 	all                   bool
 	statsController       *containerstats.Controller
-	checkUpdateInProgress map[string]struct{}
+	checkUpdateInProgress map[containerKey]struct{}
+	containers            map[containerKey]ContainerItem // containers are the items of every container, by key.
+	collapsed             map[projectKey]struct{}        // collapsed are the compose projects whose members are hidden.
+	selected              map[containerKey]struct{}      // selected are the containers a batch update applies to.
+	updateOpts            ctrlupdate.Options
+}
+
+// containerKey identifies a container across hosts.
+type containerKey struct {
+	host string
+	id   string
 }
 
 type listKeyMap struct {
@@ -39,7 +53,11 @@ type listKeyMap struct {
 	startContainer   key.Binding
 	stopContainer    key.Binding
 	updateContainer  key.Binding
+	planUpdate       key.Binding
 	execTerminal     key.Binding
+	toggleProject    key.Binding
+	toggleSelection  key.Binding
+	updateBatch      key.Binding
 }
 
 var keyMap = &listKeyMap{
@@ -67,13 +85,29 @@ var keyMap = &listKeyMap{
 		key.WithKeys("u"),
 		key.WithHelp("u", "update container"),
 	),
+	planUpdate: key.NewBinding(
+		key.WithKeys("p"),
+		key.WithHelp("p", "plan update (dry run)"),
+	),
 	execTerminal: key.NewBinding(
 		key.WithKeys("x"),
 		key.WithHelp("x", "open terminal"),
 	),
+	toggleProject: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "collapse/expand project"),
+	),
+	toggleSelection: key.NewBinding(
+		key.WithKeys(" "),
+		key.WithHelp("space", "select container"),
+	),
+	updateBatch: key.NewBinding(
+		key.WithKeys("U"),
+		key.WithHelp("U", "update selected or all outdated"),
+	),
 }
 
-func New(cli *client.Client, cache *cache.Cache) Model {
+func New(cache *cache.Group, updateOpts ctrlupdate.Options) Model {
 
 	items := []list.Item{}
 
@@ -84,36 +118,57 @@ func New(cli *client.Client, cache *cache.Cache) Model {
 			keyMap.toggleAll,
 			keyMap.showLogs,
 			keyMap.updateContainer,
+			keyMap.planUpdate,
 			keyMap.restartContainer,
 			keyMap.startContainer,
 			keyMap.stopContainer,
 			keyMap.execTerminal,
+			keyMap.toggleProject,
+			keyMap.toggleSelection,
+			keyMap.updateBatch,
 		}
 	}
 
+	ctx, cancel := context.WithCancel(context.Background())
+
 	m := Model{
-		cli:                   cli,
+		ctx:                   ctx,
+		cancel:                cancel,
 		cache:                 cache,
 		list:                  l,
 		all:                   false,
-		checkUpdateInProgress: make(map[string]struct{}),
+		checkUpdateInProgress: make(map[containerKey]struct{}),
+		containers:            make(map[containerKey]ContainerItem),
+		collapsed:             make(map[projectKey]struct{}),
+		selected:              make(map[containerKey]struct{}),
+		updateOpts:            updateOpts,
 		//imgs:   images,
 	}
 
-	m.statsController = containerstats.New(cli)
+	m.statsController = containerstats.New(ctx, cache)
 	//m.statsController.Start()
 
 	return m
 }
 
 func (m Model) Init() tea.Cmd {
-	return tea.Batch(WaitStatsEvent(m.statsController.Events()))
+	return tea.Batch(WaitStatsEvent(m.statsController.Events()), loadingTickCmd())
+}
+
+// Close cancels the pending commands of the model.
+func (m Model) Close() {
+	m.cancel()
 }
 
 func (m Model) IsSearching() bool {
 	return m.list.IsFiltered()
 }
 
+// IsFiltering reports whether the list filter is being typed.
+func (m Model) IsFiltering() bool {
+	return m.list.SettingFilter()
+}
+
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	var cmds []tea.Cmd
@@ -124,51 +179,113 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.list.SetSize(msg.Width, msg.Height-4)
 		return m, nil
 
+	case loadingTickMsg:
+		if m.loaded {
+			return m, nil
+		}
+		return m, loadingTickCmd()
+
 	case tea.KeyMsg:
+		if m.list.FilterState() == list.Filtering {
+			// keys go to the filter input, enter applies the filter.
+			break
+		}
 		switch {
 		case key.Matches(msg, keyMap.toggleAll):
 			m.ToggleAll()
 			return m, nil
 
+		case key.Matches(msg, keyMap.toggleProject):
+			if p, ok := m.list.SelectedItem().(ProjectItem); ok {
+				if p.collapsed {
+					delete(m.collapsed, p.key())
+				} else {
+					m.collapsed[p.key()] = struct{}{}
+				}
+				m.rebuild()
+			}
+			return m, nil
+
+		case key.Matches(msg, keyMap.toggleSelection):
+			m.toggleSelection()
+			return m, nil
+
+		case key.Matches(msg, keyMap.updateBatch):
+			return m, m.updateBatch()
+
 		case key.Matches(msg, keyMap.showLogs):
-			cmd := exec.Command("docker", "logs", "-f", "--tail=200", m.list.SelectedItem().(ContainerItem).id)
+			c, ok := m.list.SelectedItem().(ContainerItem)
+			if !ok {
+				return m, nil
+			}
+			cmd := m.dockerCommand(c.host, "logs", "-f", "--tail=200", c.id)
 			return m, tea.ExecProcess(cmd, func(err error) tea.Msg {
 				return nil
 			})
 
 		case key.Matches(msg, keyMap.restartContainer):
+			if p, ok := m.list.SelectedItem().(ProjectItem); ok {
+				m.status = style.StatusBar().Render("Restarting project " + p.name)
+				return m, m.projectCmd(p, commands.RestartContainerAction)
+			}
 			if c, ok := m.list.SelectedItem().(ContainerItem); ok && c.state == container.StateRunning {
-				m.updateContainerActionState(c.id, container.StateRestarting)
+				m.updateContainerActionState(c.host, c.id, container.StateRestarting)
 				m.status = style.StatusBar().Render("Restarting container " + m.list.SelectedItem().(ContainerItem).name)
-				return m, commands.ContainerCmd(m.cli, commands.RestartContainerAction, c.id)
+				return m, commands.ContainerCmd(m.ctx, m.cache.Client(c.host), commands.RestartContainerAction, c.id)
 			}
 			return m, nil
 
 		case key.Matches(msg, keyMap.startContainer):
+			if p, ok := m.list.SelectedItem().(ProjectItem); ok {
+				return m, m.projectCmd(p, commands.StartContainerAction)
+			}
 			if c, ok := m.list.SelectedItem().(ContainerItem); ok && !slices.Contains([]string{container.StateRunning, container.StateRestarting}, c.state) {
-				return m, commands.ContainerCmd(m.cli, commands.StartContainerAction, c.id)
+				return m, commands.ContainerCmd(m.ctx, m.cache.Client(c.host), commands.StartContainerAction, c.id)
 			}
 			return m, nil
 
 		case key.Matches(msg, keyMap.stopContainer):
+			if p, ok := m.list.SelectedItem().(ProjectItem); ok {
+				return m, m.projectCmd(p, commands.StopContainerAction)
+			}
 			if c, ok := m.list.SelectedItem().(ContainerItem); ok {
-				return m, commands.ContainerCmd(m.cli, commands.StopContainerAction, c.id)
+				return m, commands.ContainerCmd(m.ctx, m.cache.Client(c.host), commands.StopContainerAction, c.id)
 			}
 			return m, nil
 
 		case key.Matches(msg, keyMap.updateContainer):
+			if p, ok := m.list.SelectedItem().(ProjectItem); ok {
+				return m, m.updateProject(p)
+			}
 			if c, ok := m.list.SelectedItem().(ContainerItem); ok {
 				if c.update != nil && *c.update {
-					c, _ := m.cache.Container(c.id)
+					c, _ := m.cache.Container(c.host, c.id)
+					cli := m.cache.Client(c.Host)
 					return m, commands.SwitchPageCmd(func() tea.Model {
-						u := containerupdate.New(c, m.cli)
+						u := containerupdate.New(c, cli, m.updateOpts)
 						return u
 					})
 				}
 			}
 			return m, nil
+		case key.Matches(msg, keyMap.planUpdate):
+			if c, ok := m.list.SelectedItem().(ContainerItem); ok {
+				c, err := m.cache.Container(c.host, c.id)
+				if err != nil {
+					return m, nil
+				}
+				cli := m.cache.Client(c.Host)
+				return m, commands.SwitchPageCmd(func() tea.Model {
+					return updateplan.New(c, cli, m.updateOpts)
+				})
+			}
+			return m, nil
 		case key.Matches(msg, keyMap.execTerminal):
-			cmd := exec.Command("docker", "exec", "-it", m.list.SelectedItem().(ContainerItem).id, "/bin/sh")
+			c, ok := m.list.SelectedItem().(ContainerItem)
+			if !ok {
+				return m, nil
+			}
+			cmd := m.dockerCommand(c.host, "exec", "-it", c.id, "/bin/sh")
 			return m, tea.ExecProcess(cmd, func(err error) tea.Msg {
 				return nil
 			})
@@ -191,25 +308,23 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case ContainerUpdateMsg:
 		log.Printf("received container update event %+v", msg)
 		if msg.Err == nil {
-			for i, c := range m.list.Items() {
-				if container, ok := c.(ContainerItem); ok && container.id == msg.ContainerID {
-					b := msg.Update
-					container.update = &b
-					container.RenderContent()
-					m.list.SetItem(i, container)
-					break
-				}
+			if container, ok := m.containers[containerKey{host: msg.Host, id: msg.ContainerID}]; ok {
+				b := msg.Update
+				container.update = &b
+				container.RenderContent()
+				m.containers[container.key()] = container
+				m.rebuild()
 			}
 		} else {
 			log.Printf("error checking update for container %s: %s", msg.ContainerID, msg.Err)
 		}
-		delete(m.checkUpdateInProgress, msg.ContainerID)
+		delete(m.checkUpdateInProgress, containerKey{host: msg.Host, id: msg.ContainerID})
 	case commands.ContainerActionMsg:
 		if msg.Err != nil {
 			m.status = style.Danger().Render(msg.Err.Error())
 		} else {
 			m.status = ""
-			m.updateContainerActionState(msg.ContainerID, "")
+			m.updateContainerActionState(msg.Host, msg.ContainerID, "")
 		}
 		// case containerstats.StatsMsg:
 		// 	for i, c := range m.list.Items() {
@@ -230,6 +345,9 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 func (m Model) View() string {
 	if !m.loaded {
+		if p := m.cache.Progress(); p.ContainersTotal > 0 {
+			return fmt.Sprintf("Chargement des containers Docker... %d/%d", p.Containers, p.ContainersTotal)
+		}
 		return "Chargement des containers Docker..."
 	}
 	return lipgloss.JoinVertical(
@@ -247,13 +365,9 @@ func (m *Model) initialLoad() tea.Cmd {
 
 	var cmds = make([]tea.Cmd, 0, len(containers))
 
-	slices.SortFunc(containers, func(a, b types.Container) int {
-		return strings.Compare(a.Name, b.Name)
-	})
-
-	itemList := make([]list.Item, 0, len(containers))
+	clear(m.containers)
 	for _, item := range containers {
-		container := NewContainerItem(item)
+		container := NewContainerItem(item, m.cache.MultiHost())
 		if m.all {
 			container.show = true
 		} else {
@@ -261,132 +375,349 @@ func (m *Model) initialLoad() tea.Cmd {
 		}
 		container.RenderContent()
 		//m.statsController.AddContainer(container.id)
-		itemList = append(itemList, container)
-		m.checkUpdateInProgress[container.id] = struct{}{}
-		cmds = append(cmds, CheckContainerUpdate(m.cli, container.id))
+		m.containers[container.key()] = container
+		m.checkUpdateInProgress[container.key()] = struct{}{}
+		cmds = append(cmds, CheckContainerUpdate(m.ctx, m.cache.Client(container.host), container.id))
 	}
-	m.list.SetItems(itemList)
+	m.rebuild()
 	return tea.Batch(cmds...)
 }
 
+// rebuild sets the list items from the containers of the model.
+//
+// The containers of a compose project are listed under the header of their
+// project, unless the project is collapsed. Projects come first, sorted by
+// name then host, followed by the containers that do not belong to a project.
+// Containers are sorted by name then host. The selected item stays selected
+// when the list is not filtered.
+func (m *Model) rebuild() {
+	var selected any
+	switch item := m.list.SelectedItem().(type) {
+	case ContainerItem:
+		selected = item.key()
+	case ProjectItem:
+		selected = item.key()
+	}
+
+	members := make(map[projectKey][]ContainerItem)
+	var standalone []ContainerItem
+	for _, c := range m.containers {
+		if c.project == "" {
+			standalone = append(standalone, c)
+			continue
+		}
+		members[c.projectKey()] = append(members[c.projectKey()], c)
+	}
+
+	projects := make([]projectKey, 0, len(members))
+	for p := range members {
+		projects = append(projects, p)
+	}
+	slices.SortFunc(projects, func(a, b projectKey) int {
+		if r := strings.Compare(a.name, b.name); r != 0 {
+			return r
+		}
+		return strings.Compare(a.host, b.host)
+	})
+
+	items := make([]list.Item, 0, len(m.containers)+len(projects))
+	for _, p := range projects {
+		_, collapsed := m.collapsed[p]
+		items = append(items, newProjectItem(p, members[p], collapsed, m.cache.MultiHost()))
+		if collapsed {
+			continue
+		}
+		slices.SortFunc(members[p], compareContainers)
+		for _, c := range members[p] {
+			items = append(items, c)
+		}
+	}
+	slices.SortFunc(standalone, compareContainers)
+	for _, c := range standalone {
+		items = append(items, c)
+	}
+
+	m.list.SetItems(items)
+
+	if selected == nil || m.list.FilterState() != list.Unfiltered {
+		return
+	}
+	for i, item := range items {
+		switch item := item.(type) {
+		case ContainerItem:
+			if item.key() == selected {
+				m.list.Select(i)
+				return
+			}
+		case ProjectItem:
+			if item.key() == selected {
+				m.list.Select(i)
+				return
+			}
+		}
+	}
+}
+
+// compareContainers orders containers by name then host.
+func compareContainers(a, b ContainerItem) int {
+	if r := strings.Compare(a.Name(), b.Name()); r != 0 {
+		return r
+	}
+	return strings.Compare(a.host, b.host)
+}
+
+// members returns the containers of the given project, sorted by name.
+func (m *Model) members(p projectKey) []ContainerItem {
+	var out []ContainerItem
+	for _, c := range m.containers {
+		if c.projectKey() == p {
+			out = append(out, c)
+		}
+	}
+	slices.SortFunc(out, compareContainers)
+	return out
+}
+
+// projectCmd returns a command applying the given action to the members of
+// the given project it makes sense for: stopped members are started, running
+// members are stopped or restarted.
+func (m *Model) projectCmd(p ProjectItem, action commands.Action) tea.Cmd {
+	cli := m.cache.Client(p.host)
+	var cmds []tea.Cmd
+	for _, c := range m.members(p.key()) {
+		running := slices.Contains([]string{container.StateRunning, container.StateRestarting}, c.state)
+		switch action {
+		case commands.StartContainerAction:
+			if running {
+				continue
+			}
+		case commands.StopContainerAction:
+			if !running {
+				continue
+			}
+		case commands.RestartContainerAction:
+			if c.state != container.StateRunning {
+				continue
+			}
+			m.updateContainerActionState(c.host, c.id, container.StateRestarting)
+		}
+		cmds = append(cmds, commands.ContainerCmd(m.ctx, cli, action, c.id))
+	}
+	return tea.Batch(cmds...)
+}
+
+// updateProject returns a command opening the update screen for the outdated
+// members of the given project.
+func (m *Model) updateProject(p ProjectItem) tea.Cmd {
+	var outdated []types.Container
+	for _, c := range m.members(p.key()) {
+		if c.policy == types.UpdatePolicySkip || c.update == nil || !*c.update {
+			continue
+		}
+		if dc, err := m.cache.Container(c.host, c.id); err == nil {
+			outdated = append(outdated, dc)
+		}
+	}
+	if len(outdated) == 0 {
+		return nil
+	}
+	cli := m.cache.Client(p.host)
+	return commands.SwitchPageCmd(func() tea.Model {
+		return containerupdate.NewProject(p.name, outdated, cli, m.updateOpts)
+	})
+}
+
+// toggleSelection selects the selected container for a batch update, or
+// unselects it. On a project header, every member of the project is selected,
+// or unselected if they all were. Containers whose update policy is skip are
+// never selected.
+func (m *Model) toggleSelection() {
+	var keys []containerKey
+	switch item := m.list.SelectedItem().(type) {
+	case ContainerItem:
+		if item.policy != types.UpdatePolicySkip {
+			keys = append(keys, item.key())
+		}
+	case ProjectItem:
+		for _, c := range m.members(item.key()) {
+			if c.policy != types.UpdatePolicySkip {
+				keys = append(keys, c.key())
+			}
+		}
+	}
+
+	all := true
+	for _, k := range keys {
+		if _, ok := m.selected[k]; !ok {
+			all = false
+		}
+	}
+	for _, k := range keys {
+		c := m.containers[k]
+		if all {
+			delete(m.selected, k)
+			c.selected = false
+		} else {
+			m.selected[k] = struct{}{}
+			c.selected = true
+		}
+		c.RenderContent()
+		m.containers[k] = c
+	}
+	m.rebuild()
+}
+
+// updateBatch returns a command opening the batch update screen for the
+// selected containers, or for every outdated container if none is selected.
+// Containers whose update policy is skip are left out. The selection is
+// cleared.
+func (m *Model) updateBatch() tea.Cmd {
+	var targets []types.Container
+	for key, c := range m.containers {
+		if c.policy == types.UpdatePolicySkip {
+			continue
+		}
+		if len(m.selected) > 0 {
+			if _, ok := m.selected[key]; !ok {
+				continue
+			}
+		} else if c.update == nil || !*c.update {
+			continue
+		}
+		if dc, err := m.cache.Container(c.host, c.id); err == nil {
+			targets = append(targets, dc)
+		}
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+	slices.SortFunc(targets, func(a, b types.Container) int {
+		if r := strings.Compare(a.Name, b.Name); r != 0 {
+			return r
+		}
+		return strings.Compare(a.Host, b.Host)
+	})
+
+	for key := range m.selected {
+		if c, ok := m.containers[key]; ok {
+			c.selected = false
+			c.RenderContent()
+			m.containers[key] = c
+		}
+	}
+	clear(m.selected)
+	m.rebuild()
+
+	group, opts := m.cache, m.updateOpts
+	return commands.SwitchPageCmd(func() tea.Model {
+		return batchupdate.New(targets, group.Client, group.MultiHost(), opts)
+	})
+}
+
 // handleContainerEvent handles a container event from the cache.
 //
-// The function first retrieves the ContainerItem from the cache with the given id.
+// The function first retrieves the container from the cache with the given id.
 // If the container is not found, it removes the container from the list.
-// If the container is found, it gets the old ContainerItem from the list with the given id.
-// If the old container is not found, it adds the new container to the list.
-// If the old container is found, it updates the old container with the new container.
+// If the container is found but not known by the model, it adds the new container to the list.
+// Otherwise, it updates the known container with the new container.
 //
 // The function returns a tea.Cmd that executes the update if needed.
 func (m *Model) handleContainerEvent(msg cache.Event) tea.Cmd {
-	newContainer, err := m.cache.Container(msg.ActorID)
+	newContainer, err := m.cache.Container(msg.Host, msg.ActorID)
 
 	if err != nil {
-		m.removeContainer(msg.ActorID)
+		m.removeContainer(msg.Host, msg.ActorID)
 		return nil
 	}
 
-	oldContainer, oldContainerIdx, err := m.getContainerWithIndex(msg.ActorID)
+	oldContainer, ok := m.containers[containerKey{host: msg.Host, id: msg.ActorID}]
 
-	if err != nil {
+	if !ok {
 		return m.addNewContainer(newContainer)
 	}
 
-	return m.updateContainer(newContainer, oldContainer, oldContainerIdx)
-}
-
-// getContainerWithIndex returns the ContainerItem with the given id and its index in the model's list.
-// If the container is not found, it returns an empty ContainerItem, -1 as the index, and an error.
-func (m *Model) getContainerWithIndex(id string) (ContainerItem, int, error) {
-	for i, item := range m.list.Items() {
-		if item.(ContainerItem).id == id {
-			return item.(ContainerItem), i, nil
-		}
-	}
-	return ContainerItem{}, -1, errors.New("container not found")
+	return m.updateContainer(newContainer, oldContainer)
 }
 
 // removeContainer removes a container from the list.
-//
-// The function iterates over the list of containers and removes the first container that matches the given id.
-// If no container is found, the function does nothing.
-// The function does not return anything.
-func (m *Model) removeContainer(id string) {
-	for i, item := range m.list.Items() {
-		if item.(ContainerItem).id == id {
-			m.list.RemoveItem(i)
-			return
-		}
+// If no container matches the given host and id, the function does nothing.
+func (m *Model) removeContainer(host, id string) {
+	key := containerKey{host: host, id: id}
+	if _, ok := m.containers[key]; !ok {
+		return
 	}
+	delete(m.containers, key)
+	delete(m.selected, key)
+	m.rebuild()
 }
 
 // addNewContainer adds a new container to the list and checks for update.
 //
-// The function sorts the list by container name and adds the new container at the end.
 // It also adds the container to the list of containers to check for update.
 // The function returns a command to check for container update.
 //
 // The function also renders the content of the new container.
 func (m *Model) addNewContainer(container types.Container) tea.Cmd {
-	newContainer := NewContainerItem(container)
+	newContainer := NewContainerItem(container, m.cache.MultiHost())
 	newContainer.RenderContent()
-	items := m.list.Items()
-	items = append(items, newContainer)
-	slices.SortFunc(items, func(a, b list.Item) int {
-		return strings.Compare(a.(ContainerItem).Name(), b.(ContainerItem).Name())
-	})
-	m.list.SetItems(items)
-	m.checkUpdateInProgress[newContainer.id] = struct{}{}
-	return CheckContainerUpdate(m.cli, newContainer.id)
+	m.containers[newContainer.key()] = newContainer
+	m.rebuild()
+	m.checkUpdateInProgress[newContainer.key()] = struct{}{}
+	return CheckContainerUpdate(m.ctx, m.cache.Client(newContainer.host), newContainer.id)
 }
 
-func (m *Model) updateContainer(newContainer types.Container, oldContainer ContainerItem, index int) tea.Cmd {
+func (m *Model) updateContainer(newContainer types.Container, oldContainer ContainerItem) tea.Cmd {
 	var cmd tea.Cmd = nil
-	c := NewContainerItem(newContainer)
+	c := NewContainerItem(newContainer, m.cache.MultiHost())
 
 	// update flag
 	if oldContainer.update != nil {
 		c.update = oldContainer.update
 	} else {
-		if _, ok := m.checkUpdateInProgress[c.id]; !ok {
-			m.checkUpdateInProgress[c.id] = struct{}{}
-			cmd = CheckContainerUpdate(m.cli, c.id)
+		key := containerKey{host: c.host, id: c.id}
+		if _, ok := m.checkUpdateInProgress[key]; !ok {
+			m.checkUpdateInProgress[key] = struct{}{}
+			cmd = CheckContainerUpdate(m.ctx, m.cache.Client(c.host), c.id)
 		}
 	}
 
 	c.actionState = oldContainer.actionState
+	c.show = oldContainer.show
+	c.selected = oldContainer.selected
 
 	c.RenderContent()
-	m.list.SetItem(index, c)
+	m.containers[c.key()] = c
+	m.rebuild()
 	return cmd
 
 }
 
-func (m *Model) updateContainerActionState(id string, state string) {
-	for i, item := range m.list.Items() {
-		if c := item.(ContainerItem); c.id == id {
-			c.actionState = state
-			c.RenderContent()
-			m.list.SetItem(i, c)
-		}
+func (m *Model) updateContainerActionState(host, id string, state string) {
+	c, ok := m.containers[containerKey{host: host, id: id}]
+	if !ok {
+		return
+	}
+	c.actionState = state
+	c.RenderContent()
+	m.containers[c.key()] = c
+	m.rebuild()
+}
+
+// dockerCommand returns a docker CLI command reaching the daemon of the given host.
+func (m *Model) dockerCommand(host string, args ...string) *exec.Cmd {
+	var global []string
+	if cli := m.cache.Client(host); cli != nil {
+		global = cli.Endpoint().CLIArgs()
 	}
+	return exec.Command("docker", append(global, args...)...)
 }
 
 func (m *Model) ToggleAll() {
 	m.all = !m.all
 
-	for i, item := range m.list.Items() {
-		if c, ok := item.(ContainerItem); ok {
-			if m.all {
-				c.show = true
-				m.list.SetItem(i, c)
-			} else {
-				c.show = false
-				if c.state == container.StateRunning || c.state == container.StateRestarting {
-					c.show = true
-				}
-				m.list.SetItem(i, c)
-			}
-		}
+	for key, c := range m.containers {
+		c.show = m.all || c.state == container.StateRunning || c.state == container.StateRestarting
+		m.containers[key] = c
 	}
+	m.rebuild()
 }
@@ -0,0 +1,119 @@
+package containers
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/docker/docker/api/types/container"
+	style "github.com/kdruelle/gmd/tui/styles"
+)
+
+// projectKey identifies a compose project across hosts.
+type projectKey struct {
+	host string
+	name string
+}
+
+// ProjectItem is the header of the section of the containers of a compose
+// project. It shows the aggregate state of the members of the project.
+type ProjectItem struct {
+	host      string
+	name      string
+	running   int   // running is the number of running members.
+	total     int   // total is the number of members.
+	update    *bool // update is nil until every member was checked, true if one of them is outdated.
+	collapsed bool
+	showHost  bool
+}
+
+// newProjectItem returns the header of the given project computed from its
+// members.
+func newProjectItem(key projectKey, members []ContainerItem, collapsed, showHost bool) ProjectItem {
+	p := ProjectItem{
+		host:      key.host,
+		name:      key.name,
+		total:     len(members),
+		collapsed: collapsed,
+		showHost:  showHost,
+	}
+
+	checked := true
+	outdated := false
+	for _, c := range members {
+		if c.state == container.StateRunning {
+			p.running++
+		}
+		switch {
+		case c.update == nil:
+			checked = false
+		case *c.update:
+			outdated = true
+		}
+	}
+	if outdated || checked {
+		p.update = &outdated
+	}
+	return p
+}
+
+func (p ProjectItem) key() projectKey {
+	return projectKey{host: p.host, name: p.name}
+}
+
+func (p ProjectItem) Name() string {
+	return p.name
+}
+
+// Status returns the number of running members out of the members of the
+// project, colored after how many of them run.
+func (p ProjectItem) Status() string {
+	status := fmt.Sprintf("%d/%d running", p.running, p.total)
+	switch {
+	case p.running == p.total:
+		return style.Success().Render(status)
+	case p.running == 0:
+		return style.Danger().Render(status)
+	default:
+		return style.Warning().Render(status)
+	}
+}
+
+func (p ProjectItem) UpdateFlag() string {
+	if p.update == nil {
+		return UpdateUnavailable
+	}
+	if *p.update {
+		return UpdateAvailableFlag
+	}
+	return UpToDateFlag
+}
+
+func (p ProjectItem) Render(selected bool) string {
+	arrow := "▾"
+	if p.collapsed {
+		arrow = "▸"
+	}
+
+	title := style.Title().Render(arrow + " " + p.name)
+	subtitle := style.Subtitle().Render("compose project")
+
+	col1 := colNameStyle.Render(lipgloss.JoinVertical(lipgloss.Left, title, subtitle))
+	col2 := colStateStyle.Render(lipgloss.JoinHorizontal(lipgloss.Center, p.UpdateFlag(), " ", p.Status()))
+
+	if selected {
+		col1 = style.Bold().Render(col1)
+		col2 = style.Bold().Render(col2)
+	}
+
+	if p.showHost {
+		col0 := colHostStyle.Render(style.Subtitle().Render(p.host))
+		if selected {
+			col0 = style.Bold().Render(col0)
+		}
+		return lipgloss.JoinHorizontal(lipgloss.Center, col0, col1, " ", col2)
+	}
+
+	return lipgloss.JoinHorizontal(lipgloss.Center, col1, " ", col2)
+}
+
+func (p ProjectItem) FilterValue() string { return p.name }
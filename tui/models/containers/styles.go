@@ -6,16 +6,21 @@ import (
 )
 
 var (
-	colNameStyle    = lipgloss.NewStyle().Width(40)
-	colStateStyle   = lipgloss.NewStyle().Width(20)
-	colImageStyle   = lipgloss.NewStyle().Width(70)
-	colAddressStyle = lipgloss.NewStyle().Width(40)
+	colHostStyle       = lipgloss.NewStyle().Width(20)
+	colNameStyle       = lipgloss.NewStyle().Width(40)
+	colMemberNameStyle = colNameStyle.PaddingLeft(2)
+	colStateStyle      = lipgloss.NewStyle().Width(20)
+	colImageStyle      = lipgloss.NewStyle().Width(70)
+	colAddressStyle    = lipgloss.NewStyle().Width(40)
 )
 
 var (
 	UpdateUnavailable   = style.Inactive().Render("-")
 	UpToDateFlag        = style.Success().Render("✓")
 	UpdateAvailableFlag = style.Danger().Render("⚠")
+	SelectedFlag        = style.Warning().Render("●")
+	PolicySkipFlag      = style.Inactive().Render("⊘")
+	PolicyAutoFlag      = style.Success().Render("↻")
 )
 
 var (
@@ -1,6 +1,8 @@
 package containerupdate
 
 import (
+	"context"
+
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/kdruelle/gmd/docker/types"
 	"github.com/kdruelle/gmd/tui/controllers/containerupdate"
@@ -9,9 +11,9 @@ import (
 type UpdateFinishedMsg struct {
 }
 
-func startUpdate(c *containerupdate.Controller, container types.Container) tea.Cmd {
+func startUpdate(ctx context.Context, c *containerupdate.Controller, containers []types.Container) tea.Cmd {
 	return func() tea.Msg {
-		c.StartUpdate(container)
+		c.StartUpdate(ctx, containers...)
 		return containerupdate.ControllerUpdateMsg{}
 	}
 }
@@ -1,6 +1,7 @@
 package containerupdate
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"strings"
@@ -15,7 +16,9 @@ import (
 )
 
 type Model struct {
-	container  types.Container
+	ctx        context.Context
+	cancel     context.CancelFunc
+	containers []types.Container
 	cli        *client.Client
 	controller *containerupdate.Controller
 	screenW    int
@@ -26,7 +29,10 @@ type Model struct {
 }
 
 type listKeyMap struct {
-	returnKey key.Binding
+	returnKey   key.Binding
+	cancelKey   key.Binding
+	rollbackKey key.Binding
+	keepKey     key.Binding
 }
 
 var keyMap = &listKeyMap{
@@ -34,12 +40,37 @@ var keyMap = &listKeyMap{
 		key.WithKeys("esc", "enter"),
 		key.WithHelp("enter", "get back to main menu"),
 	),
+	cancelKey: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "cancel and get back to main menu"),
+	),
+	rollbackKey: key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "roll back to the previous image"),
+	),
+	keepKey: key.NewBinding(
+		key.WithKeys("k"),
+		key.WithHelp("k", "keep the new container"),
+	),
+}
+
+func New(c types.Container, client *client.Client, opts containerupdate.Options) Model {
+	return newModel(fmt.Sprintf("Updating container %s ...", strings.TrimPrefix(c.Name, "/")), []types.Container{c}, client, opts)
+}
+
+// NewProject returns a model updating the given containers of a compose
+// project one after the other.
+func NewProject(project string, containers []types.Container, client *client.Client, opts containerupdate.Options) Model {
+	return newModel(fmt.Sprintf("Updating project %s ...", project), containers, client, opts)
 }
 
-func New(c types.Container, client *client.Client) Model {
-	controller := containerupdate.New(client)
+func newModel(titleText string, containers []types.Container, client *client.Client, opts containerupdate.Options) Model {
+	controller := containerupdate.New(client, opts)
+	ctx, cancel := context.WithCancel(context.Background())
 	m := Model{
-		container:  c,
+		ctx:        ctx,
+		cancel:     cancel,
+		containers: containers,
 		cli:        client,
 		controller: controller,
 	}
@@ -49,7 +80,7 @@ func New(c types.Container, client *client.Client) Model {
 		Foreground(lipgloss.Color("#88C0D0")).
 		Width(90).
 		Align(lipgloss.Center).
-		Render(fmt.Sprintf("Updating container %s ...", strings.TrimPrefix(m.container.Name, "/")))
+		Render(titleText)
 
 	m.titleBlock = lipgloss.JoinVertical(
 		lipgloss.Center,
@@ -61,8 +92,15 @@ func New(c types.Container, client *client.Client) Model {
 }
 
 func (m Model) Init() tea.Cmd {
-	log.Printf("init update for container %s", m.container.Name)
-	return startUpdate(m.controller, m.container)
+	for _, c := range m.containers {
+		log.Printf("init update for container %s", c.Name)
+	}
+	return startUpdate(m.ctx, m.controller, m.containers)
+}
+
+// Close cancels the update if it is still pulling the image.
+func (m Model) Close() {
+	m.cancel()
 }
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -77,10 +115,17 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.completed = true
 	case tea.KeyMsg:
 		switch {
+		case key.Matches(msg, keyMap.rollbackKey) && m.controller.AskingRollback():
+			m.controller.Rollback()
+		case key.Matches(msg, keyMap.keepKey) && m.controller.AskingRollback():
+			m.controller.Keep()
 		case key.Matches(msg, keyMap.returnKey):
 			if m.completed {
 				return m, commands.SwitchPageCmd(nil)
 			}
+			if key.Matches(msg, keyMap.cancelKey) {
+				return m, commands.SwitchPageCmd(nil)
+			}
 		}
 
 	}
@@ -0,0 +1,35 @@
+package contexts
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kdruelle/gmd/docker/client"
+	"github.com/kdruelle/gmd/tui/commands"
+)
+
+type ContextsLoadedMsg struct {
+	Endpoints []client.Endpoint
+	Err       error
+}
+
+type connectErrorMsg struct {
+	Err error
+}
+
+func loadContextsCmd() tea.Cmd {
+	return func() tea.Msg {
+		endpoints, err := client.ListContexts()
+		return ContextsLoadedMsg{Endpoints: endpoints, Err: err}
+	}
+}
+
+// connectCmd builds a client for the given endpoint and asks the root model
+// to switch to it.
+func connectCmd(endpoint client.Endpoint, timeouts client.Timeouts) tea.Cmd {
+	return func() tea.Msg {
+		cli, err := client.NewClientFromEndpoint(endpoint, timeouts)
+		if err != nil {
+			return connectErrorMsg{Err: err}
+		}
+		return commands.SwitchClientMsg{Client: cli}
+	}
+}
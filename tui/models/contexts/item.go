@@ -0,0 +1,26 @@
+package contexts
+
+import (
+	"github.com/kdruelle/gmd/docker/client"
+)
+
+type ContextItem struct {
+	endpoint client.Endpoint
+	current  bool
+}
+
+func (i ContextItem) Title() string {
+	if i.current {
+		return i.endpoint.Name + " *"
+	}
+	return i.endpoint.Name
+}
+
+func (i ContextItem) Description() string {
+	if i.endpoint.Description != "" {
+		return i.endpoint.Host + " - " + i.endpoint.Description
+	}
+	return i.endpoint.Host
+}
+
+func (i ContextItem) FilterValue() string { return i.endpoint.Name }
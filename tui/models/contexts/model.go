@@ -0,0 +1,152 @@
+package contexts
+
+import (
+	"slices"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/kdruelle/gmd/docker/client"
+	"github.com/kdruelle/gmd/tui/commands"
+	style "github.com/kdruelle/gmd/tui/styles"
+)
+
+// Model is the screen listing the docker contexts, used to switch the
+// daemon gmd is connected to.
+type Model struct {
+	current  []client.Endpoint
+	timeouts client.Timeouts
+	list     list.Model
+	loaded   bool
+	status   string
+}
+
+type listKeyMap struct {
+	selectContext key.Binding
+	back          key.Binding
+}
+
+var keyMap = &listKeyMap{
+	selectContext: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "connect to context"),
+	),
+	back: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "get back to main menu"),
+	),
+}
+
+func New(current []client.Endpoint, timeouts client.Timeouts) Model {
+
+	l := list.New([]list.Item{}, newItemDelegate(), 0, 0)
+	l.Title = "Docker contexts"
+	l.AdditionalFullHelpKeys = func() []key.Binding {
+		return []key.Binding{
+			keyMap.selectContext,
+			keyMap.back,
+		}
+	}
+
+	return Model{
+		current:  current,
+		timeouts: timeouts,
+		list:     l,
+	}
+}
+
+func (m Model) Init() tea.Cmd {
+	return loadContextsCmd()
+}
+
+func (m Model) IsSearching() bool {
+	return m.list.IsFiltered()
+}
+
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+
+	switch msg := msg.(type) {
+
+	case tea.WindowSizeMsg:
+		m.list.SetSize(msg.Width, msg.Height-2)
+		return m, nil
+
+	case ContextsLoadedMsg:
+		m.loaded = true
+		if msg.Err != nil {
+			m.status = style.Danger().Render(msg.Err.Error())
+		}
+		m.setEndpoints(msg.Endpoints)
+		return m, nil
+
+	case connectErrorMsg:
+		m.status = style.Danger().Render(msg.Err.Error())
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.list.SettingFilter() {
+			break
+		}
+		switch {
+		case key.Matches(msg, keyMap.back) && m.list.FilterState() == list.Unfiltered:
+			return m, commands.SwitchPageCmd(nil)
+
+		case key.Matches(msg, keyMap.selectContext):
+			item, ok := m.list.SelectedItem().(ContextItem)
+			if !ok {
+				return m, nil
+			}
+			if item.current && len(m.current) == 1 {
+				return m, commands.SwitchPageCmd(nil)
+			}
+			m.status = style.StatusBar().Render("Connecting to " + item.endpoint.Name)
+			return m, connectCmd(item.endpoint, m.timeouts)
+		}
+	}
+
+	newList, cmd := m.list.Update(msg)
+	m.list = newList
+	return m, cmd
+}
+
+func (m Model) View() string {
+	if !m.loaded {
+		return "Chargement des contextes Docker..."
+	}
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		m.list.View(),
+		m.status,
+	)
+}
+
+// setEndpoints fills the list with the given endpoints and selects the
+// first current one. Current endpoints are added when they do not come from
+// the contexts store, e.g. when gmd was started with --host.
+func (m *Model) setEndpoints(endpoints []client.Endpoint) {
+	items := make([]list.Item, 0, len(endpoints)+len(m.current))
+	found := make(map[client.Endpoint]bool, len(m.current))
+	selected := -1
+	for _, e := range endpoints {
+		current := slices.Contains(m.current, e)
+		if current {
+			found[e] = true
+			if selected < 0 {
+				selected = len(items)
+			}
+		}
+		items = append(items, ContextItem{endpoint: e, current: current})
+	}
+	for _, e := range m.current {
+		if found[e] {
+			continue
+		}
+		if selected < 0 {
+			selected = len(items)
+		}
+		items = append(items, ContextItem{endpoint: e, current: true})
+	}
+	m.list.SetItems(items)
+	m.list.Select(max(selected, 0))
+}
@@ -1,11 +1,21 @@
 package images
 
 import (
-	"context"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
 
+// loadingTickMsg asks the model to render the loading progress again.
+type loadingTickMsg struct{}
+
+// loadingTickCmd returns a command sending a loadingTickMsg shortly.
+func loadingTickCmd() tea.Cmd {
+	return tea.Tick(200*time.Millisecond, func(time.Time) tea.Msg {
+		return loadingTickMsg{}
+	})
+}
+
 type ImagesLoadedMsg struct {
 	Images []ImageItem
 	Err    error
@@ -27,9 +37,10 @@ func (m Model) FetchImagesCmd() tea.Cmd {
 	}
 }
 
-func (m Model) DeleteImagesCmd(id string) tea.Cmd {
+func (m Model) DeleteImagesCmd(host, id string) tea.Cmd {
+	cli := m.cache.Client(host)
 	return func() tea.Msg {
-		err := m.cli.DeleteImage(context.Background(), id)
+		err := cli.DeleteImage(m.ctx, id)
 		if err != nil {
 			return DeleteImageMsg{ID: id, Err: err}
 		}
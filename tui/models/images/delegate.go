@@ -12,11 +12,12 @@ import (
 
 type ItemDelegate struct {
 	list.DefaultDelegate
+	showHost bool
 }
 
-func newItemDelegate() list.ItemDelegate {
+func newItemDelegate(showHost bool) list.ItemDelegate {
 	d := list.NewDefaultDelegate()
-	return ItemDelegate{d}
+	return ItemDelegate{DefaultDelegate: d, showHost: showHost}
 }
 
 func (d ItemDelegate) Height() int  { return 2 }
@@ -33,6 +34,9 @@ func (d ItemDelegate) Render(w io.Writer, m list.Model, index int, item list.Ite
 
 	title := style.Title().Render(c.Title())
 	desc := style.Subtitle().Render(c.Description())
+	if d.showHost {
+		desc = style.Subtitle().Render(c.Host + " - " + c.Description())
+	}
 
 	content := lipgloss.JoinVertical(lipgloss.Left, title, desc)
 
@@ -1,6 +1,8 @@
 package images
 
 import (
+	"context"
+	"fmt"
 	"log"
 	"slices"
 	"strings"
@@ -10,14 +12,14 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/kdruelle/gmd/docker/cache"
-	"github.com/kdruelle/gmd/docker/client"
 	"github.com/kdruelle/gmd/docker/types"
 	style "github.com/kdruelle/gmd/tui/styles"
 )
 
 type Model struct {
-	cli    *client.Client
-	cache  *cache.Cache
+	ctx    context.Context
+	cancel context.CancelFunc
+	cache  *cache.Group
 	list   list.Model
 	loaded bool
 	unused bool
@@ -40,11 +42,11 @@ var keyMap = &listKeyMap{
 	),
 }
 
-func New(cli *client.Client, cache *cache.Cache) Model {
+func New(cache *cache.Group) Model {
 
 	items := []list.Item{}
 
-	l := list.New(items, newItemDelegate(), 0, 0)
+	l := list.New(items, newItemDelegate(cache.MultiHost()), 0, 0)
 	l.Title = "Images"
 	l.AdditionalFullHelpKeys = func() []key.Binding {
 		return []key.Binding{
@@ -53,22 +55,35 @@ func New(cli *client.Client, cache *cache.Cache) Model {
 		}
 	}
 
+	ctx, cancel := context.WithCancel(context.Background())
+
 	return Model{
-		cli:   cli,
-		cache: cache,
-		list:  l,
+		ctx:    ctx,
+		cancel: cancel,
+		cache:  cache,
+		list:   l,
 		//imgs:   images,
 	}
 }
 
 func (m Model) Init() tea.Cmd {
-	return nil
+	return loadingTickCmd()
+}
+
+// Close cancels the pending commands of the model.
+func (m Model) Close() {
+	m.cancel()
 }
 
 func (m Model) IsSearching() bool {
 	return m.list.IsFiltered()
 }
 
+// IsFiltering reports whether the list filter is being typed.
+func (m Model) IsFiltering() bool {
+	return m.list.SettingFilter()
+}
+
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
@@ -85,6 +100,19 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.list.SetSize(msg.Width, msg.Height-4)
 		return m, nil
 
+	case loadingTickMsg:
+		// the intermediate images are read once the images are loaded, their
+		// progress is shown in the title until they are all read.
+		p := m.cache.Progress()
+		if m.loaded && p.Images >= p.ImagesTotal {
+			m.list.Title = "Images"
+			return m, nil
+		}
+		if m.loaded {
+			m.list.Title = fmt.Sprintf("Images (history %d/%d)", p.Images, p.ImagesTotal)
+		}
+		return m, loadingTickCmd()
+
 	case tea.KeyMsg:
 		switch {
 		case key.Matches(msg, keyMap.toggleUnused):
@@ -92,8 +120,9 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.applyFilter()
 			return m, nil
 		case key.Matches(msg, keyMap.delete):
-			m.status = style.StatusBar().Render("Deleting image " + m.list.SelectedItem().(ImageItem).Title())
-			return m, m.DeleteImagesCmd(m.list.SelectedItem().(ImageItem).ID)
+			img := m.list.SelectedItem().(ImageItem)
+			m.status = style.StatusBar().Render("Deleting image " + img.Title())
+			return m, m.DeleteImagesCmd(img.Host, img.ID)
 		}
 
 	case DeleteImageMsg:
@@ -111,10 +140,14 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			log.Printf("received images loaded event: %+v", msg)
 			m.applyFilter()
 		}
+		if msg.EventType == cache.ImagesHistoryLoadedEventType && m.loaded {
+			log.Printf("received images history loaded event: %+v", msg)
+			m.applyFilter()
+		}
 		if msg.EventType == cache.ImageEventType {
 			if m.loaded {
 				log.Printf("received image event: %+v", msg)
-				m.updateImage(msg.ActorID)
+				m.updateImage(msg.Host, msg.ActorID)
 				// m.applyFilter()
 			}
 
@@ -148,10 +181,6 @@ func (m *Model) applyFilter() {
 		images = m.cache.Images()
 	}
 
-	slices.SortFunc(images, func(a, b types.Image) int {
-		return strings.Compare(a.Tag(), b.Tag())
-	})
-
 	itemList := make([]list.Item, 0, len(images))
 	for _, item := range images {
 
@@ -161,10 +190,10 @@ func (m *Model) applyFilter() {
 	m.list.SetItems(itemList)
 }
 
-func (m *Model) updateImage(id string) {
-	newImage, err := m.cache.Image(id)
+func (m *Model) updateImage(host, id string) {
+	newImage, err := m.cache.Image(host, id)
 	for i, item := range m.list.Items() {
-		if item.(ImageItem).ID == id {
+		if item.(ImageItem).ID == id && item.(ImageItem).Host == host {
 			switch err {
 			case cache.ErrImageNotFound:
 				m.list.RemoveItem(i)
@@ -174,10 +203,16 @@ func (m *Model) updateImage(id string) {
 			return
 		}
 	}
+	if err != nil {
+		return
+	}
 	items := m.list.Items()
 	items = append(items, ImageItem(newImage))
 	slices.SortFunc(items, func(a, b list.Item) int {
-		return strings.Compare(a.(ImageItem).Title(), b.(ImageItem).Title())
+		if r := strings.Compare(a.(ImageItem).Title(), b.(ImageItem).Title()); r != 0 {
+			return r
+		}
+		return strings.Compare(a.(ImageItem).Host, b.(ImageItem).Host)
 	})
 	m.list.SetItems(items)
 }
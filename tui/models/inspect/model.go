@@ -0,0 +1,75 @@
+// Package inspect provides a screen showing the details of a Docker object
+// as indented JSON, like docker inspect does.
+package inspect
+
+import (
+	"encoding/json"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/kdruelle/gmd/tui/commands"
+	style "github.com/kdruelle/gmd/tui/styles"
+)
+
+type Model struct {
+	title    string
+	content  string
+	viewport viewport.Model
+}
+
+type listKeyMap struct {
+	back key.Binding
+}
+
+var keyMap = &listKeyMap{
+	back: key.NewBinding(
+		key.WithKeys("esc", "enter"),
+		key.WithHelp("esc", "get back"),
+	),
+}
+
+// New returns a screen showing the given object under the given title.
+func New(title string, object any) Model {
+	content, err := json.MarshalIndent(object, "", "  ")
+	if err != nil {
+		content = []byte(err.Error())
+	}
+	return Model{
+		title:    title,
+		content:  string(content),
+		viewport: viewport.New(0, 0),
+	}
+}
+
+func (m Model) Init() tea.Cmd {
+	return nil
+}
+
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.viewport.Width = msg.Width
+		m.viewport.Height = msg.Height - 3
+		m.viewport.SetContent(m.content)
+		return m, nil
+	case tea.KeyMsg:
+		if key.Matches(msg, keyMap.back) {
+			return m, commands.SwitchPageCmd(nil)
+		}
+	}
+
+	var cmd tea.Cmd
+	m.viewport, cmd = m.viewport.Update(msg)
+	return m, cmd
+}
+
+func (m Model) View() string {
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		style.Title().Render(m.title),
+		m.viewport.View(),
+		style.Inactive().Render(keyMap.back.Help().Key+" "+keyMap.back.Help().Desc),
+	)
+}
@@ -0,0 +1,108 @@
+// Package loaderror provides the screen shown when the daemons could not be
+// loaded, from which the user can retry or go on with what was loaded.
+package loaderror
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/kdruelle/gmd/tui/commands"
+	style "github.com/kdruelle/gmd/tui/styles"
+)
+
+type Model struct {
+	err     error
+	screenW int
+	screenH int
+}
+
+type listKeyMap struct {
+	retry key.Binding
+	back  key.Binding
+	quit  key.Binding
+}
+
+var keyMap = &listKeyMap{
+	retry: key.NewBinding(
+		key.WithKeys("r", "enter"),
+		key.WithHelp("r", "retry"),
+	),
+	back: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "continue with what was loaded"),
+	),
+	quit: key.NewBinding(
+		key.WithKeys("q"),
+		key.WithHelp("q", "quit"),
+	),
+}
+
+// New returns the error screen of the given loading error.
+func New(err error) Model {
+	return Model{err: err}
+}
+
+func (m Model) Init() tea.Cmd {
+	return nil
+}
+
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.screenW = msg.Width
+		m.screenH = msg.Height
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, keyMap.retry):
+			return m, commands.ReloadCmd()
+		case key.Matches(msg, keyMap.back):
+			return m, commands.SwitchPageCmd(nil)
+		}
+	}
+	return m, nil
+}
+
+func (m Model) View() string {
+	title := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(style.ColorDanger()).
+		Width(86).
+		Align(lipgloss.Center).
+		Render("Unable to load the Docker daemon")
+
+	// errors.Join separates the errors of every host with a new line.
+	lines := strings.Split(m.err.Error(), "\n")
+	for i := range lines {
+		lines[i] = style.Danger().Width(86).Render("• " + lines[i])
+	}
+
+	help := style.Inactive().Render(strings.Join([]string{
+		keyMap.retry.Help().Key + " " + keyMap.retry.Help().Desc,
+		keyMap.back.Help().Key + " " + keyMap.back.Help().Desc,
+		keyMap.quit.Help().Key + " " + keyMap.quit.Help().Desc,
+	}, " • "))
+
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		title,
+		"",
+		lipgloss.JoinVertical(lipgloss.Left, lines...),
+		"",
+		help,
+	)
+
+	box := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(style.ColorDanger()).
+		Padding(1, 2).
+		Width(90).
+		Align(lipgloss.Left)
+
+	return lipgloss.Place(
+		m.screenW, m.screenH,
+		lipgloss.Center, lipgloss.Center,
+		box.Render(content),
+	)
+}
@@ -1,13 +1,22 @@
 package maintab
 
 import (
+	"fmt"
+	"strings"
+
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/kdruelle/gmd/docker/cache"
 	"github.com/kdruelle/gmd/docker/client"
+	"github.com/kdruelle/gmd/tui/commands"
 	"github.com/kdruelle/gmd/tui/componants"
+	"github.com/kdruelle/gmd/tui/controllers/containerupdate"
 	"github.com/kdruelle/gmd/tui/models/containers"
+	"github.com/kdruelle/gmd/tui/models/contexts"
 	"github.com/kdruelle/gmd/tui/models/images"
+	"github.com/kdruelle/gmd/tui/models/networks"
+	"github.com/kdruelle/gmd/tui/models/updatehistory"
+	"github.com/kdruelle/gmd/tui/models/volumes"
 	style "github.com/kdruelle/gmd/tui/styles"
 )
 
@@ -24,36 +33,67 @@ type SwitchTabMsg int
 const (
 	imagesTabIndex     = 0
 	containersTabIndex = 1
+	volumesTabIndex    = 2
+	networksTabIndex   = 3
 )
 
 type Model struct {
-	cache     *cache.Cache
-	lists     []componants.ListModel
-	activeTab int
+	cache        *cache.Group
+	lists        []componants.ListModel
+	activeTab    int
+	disconnected map[string]struct{} // disconnected is the set of hosts whose event stream is reconnecting.
+	historyPath  string              // historyPath is the path of the update history file, empty if updates are not recorded.
 }
 
-func New(cli *client.Client, cache *cache.Cache) Model {
+func New(cache *cache.Group, updateOpts containerupdate.Options) Model {
 
 	m := Model{
-		cache: cache,
-		lists: make([]componants.ListModel, 2),
+		cache:        cache,
+		lists:        make([]componants.ListModel, 4),
+		disconnected: make(map[string]struct{}),
+		historyPath:  updateOpts.History,
 	}
 
-	m.lists[imagesTabIndex] = images.New(cli, cache)
-	m.lists[containersTabIndex] = containers.New(cli, cache)
+	m.lists[imagesTabIndex] = images.New(cache)
+	m.lists[containersTabIndex] = containers.New(cache, updateOpts)
+	m.lists[volumesTabIndex] = volumes.New(cache)
+	m.lists[networksTabIndex] = networks.New(cache)
 	return m
 }
 
 func (m Model) Init() tea.Cmd {
-	cmds := make([]tea.Cmd, 0, 2)
+	cmds := make([]tea.Cmd, 0, len(m.lists))
 	for i := range m.lists {
 		cmds = append(cmds, m.lists[i].Init())
 	}
 	return tea.Batch(cmds...)
 }
 
+// Close cancels the pending commands of every tab.
+func (m Model) Close() {
+	for i := range m.lists {
+		if closable, ok := m.lists[i].(componants.Closable); ok {
+			closable.Close()
+		}
+	}
+}
+
 func (m Model) IsSearching() bool {
-	return m.lists[m.activeTab].(componants.Searchable).IsSearching()
+	return m.lists[m.activeTab].(componants.Searchable).IsSearching() || m.isPrompting()
+}
+
+// isPrompting reports whether the active tab waits for an answer, in which
+// case it receives every key stroke.
+func (m Model) isPrompting() bool {
+	p, ok := m.lists[m.activeTab].(componants.Prompting)
+	return ok && p.IsPrompting()
+}
+
+// isFiltering reports whether the filter of the active tab is being typed, in
+// which case the shortcuts are part of the filter.
+func (m Model) isFiltering() bool {
+	f, ok := m.lists[m.activeTab].(componants.Filtering)
+	return ok && f.IsFiltering()
 }
 
 // ---------------------------------------------------
@@ -65,6 +105,12 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 
 	case tea.KeyMsg:
+		if m.isPrompting() || m.isFiltering() {
+			l, cmd := m.lists[m.activeTab].Update(msg)
+			m.lists[m.activeTab] = l
+			return m, cmd
+		}
+
 		switch msg.String() {
 
 		case "tab", "ctrl+tab":
@@ -79,6 +125,22 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			return m, nil
 
+		case "C":
+			endpoints := m.cache.Endpoints()
+			timeouts := m.cache.Timeouts()
+			return m, commands.SwitchPageCmd(func() tea.Model {
+				return contexts.New(endpoints, timeouts)
+			})
+
+		case "H":
+			if m.historyPath == "" {
+				return m, nil
+			}
+			path, multiHost := m.historyPath, m.cache.MultiHost()
+			return m, commands.SwitchPageCmd(func() tea.Model {
+				return updatehistory.New(path, multiHost)
+			})
+
 		}
 
 		// Pass key stroke to active tab
@@ -88,7 +150,13 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case cache.Event:
 		switch msg.EventType {
-		case cache.ImagesLoadedEventType, cache.ImageEventType:
+		case cache.DisconnectedEventType:
+			m.disconnected[msg.Host] = struct{}{}
+			return m, nil
+		case cache.ReconnectedEventType:
+			delete(m.disconnected, msg.Host)
+			return m, nil
+		case cache.ImagesLoadedEventType, cache.ImagesHistoryLoadedEventType, cache.ImageEventType:
 			l, cmd := m.lists[imagesTabIndex].Update(msg)
 			m.lists[imagesTabIndex] = l
 			return m, cmd
@@ -96,7 +164,26 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			l, cmd := m.lists[containersTabIndex].Update(msg)
 			m.lists[containersTabIndex] = l
 			return m, cmd
+		case cache.VolumesLoadedEventType, cache.VolumeEventType:
+			l, cmd := m.lists[volumesTabIndex].Update(msg)
+			m.lists[volumesTabIndex] = l
+			return m, cmd
+		case cache.NetworksLoadedEventType, cache.NetworkEventType:
+			l, cmd := m.lists[networksTabIndex].Update(msg)
+			m.lists[networksTabIndex] = l
+			return m, cmd
 		}
+
+	case commands.ChangeMsg:
+		// The volumes and networks tabs subscribe to the cache changes, each
+		// one ignores the changes of the other subscription.
+		var cmds []tea.Cmd
+		for _, i := range []int{volumesTabIndex, networksTabIndex} {
+			l, cmd := m.lists[i].Update(msg)
+			m.lists[i] = l
+			cmds = append(cmds, cmd)
+		}
+		return m, tea.Batch(cmds...)
 	}
 
 	// pass all events to all lists
@@ -127,6 +214,8 @@ func (m Model) viewTabs() string {
 	var (
 		tabImages     = style.Inactive().Render(" Images ")
 		tabContainers = style.Inactive().Render(" Containers ")
+		tabVolumes    = style.Inactive().Render(" Volumes ")
+		tabNetworks   = style.Inactive().Render(" Networks ")
 	)
 
 	switch m.activeTab {
@@ -134,9 +223,52 @@ func (m Model) viewTabs() string {
 		tabImages = style.Success().Render(" Images ")
 	case containersTabIndex:
 		tabContainers = style.Success().Render(" Containers ")
+	case volumesTabIndex:
+		tabVolumes = style.Success().Render(" Volumes ")
+	case networksTabIndex:
+		tabNetworks = style.Success().Render(" Networks ")
+	}
+
+	return lipgloss.JoinHorizontal(lipgloss.Left, tabImages, tabContainers, tabVolumes, tabNetworks, "   ", m.viewEndpoint(), m.viewConnection())
+}
+
+// viewConnection renders the hosts whose event stream was lost and is being
+// reconnected, since their content may be stale until then.
+func (m Model) viewConnection() string {
+	if len(m.disconnected) == 0 {
+		return ""
+	}
+	if !m.cache.MultiHost() {
+		return style.Danger().Render("   disconnected, reconnecting…")
 	}
 
-	return lipgloss.JoinHorizontal(lipgloss.Left, tabImages, tabContainers)
+	var hosts []string
+	for _, host := range m.cache.Hosts() {
+		if _, ok := m.disconnected[host]; ok {
+			hosts = append(hosts, host)
+		}
+	}
+	return style.Danger().Render("   " + strings.Join(hosts, ", ") + " disconnected, reconnecting…")
+}
+
+// viewEndpoint renders the daemon the tabs are showing, as the context name
+// followed by its host when they differ. With several daemons, only their
+// names are listed.
+func (m Model) viewEndpoint() string {
+	endpoints := m.cache.Endpoints()
+	if len(endpoints) > 1 {
+		return style.Subtitle().Render(fmt.Sprintf("%d hosts: %s", len(endpoints), strings.Join(m.cache.Hosts(), ", ")))
+	}
+
+	e := endpoints[0]
+	endpoint := style.Subtitle().Render(e.Name)
+	if e.Host != e.Name {
+		endpoint = lipgloss.JoinHorizontal(lipgloss.Left, endpoint, style.Inactive().Render(" ("+e.Host+")"))
+	}
+	if m.cache.Clients()[0].Kind() == client.PodmanKind {
+		endpoint = lipgloss.JoinHorizontal(lipgloss.Left, endpoint, style.Inactive().Render(" [podman]"))
+	}
+	return endpoint
 }
 
 func (m Model) viewContent() string {
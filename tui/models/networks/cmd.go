@@ -0,0 +1,50 @@
+package networks
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/docker/docker/api/types/network"
+)
+
+// NetworkActionMsg reports the result of an action on a network.
+type NetworkActionMsg struct {
+	Host    string
+	Network string
+	Done    string // Done describes the action once done.
+	Err     error
+}
+
+func (m Model) CreateNetworkCmd(host, name string) tea.Cmd {
+	cli := m.cache.Client(host)
+	return func() tea.Msg {
+		_, err := cli.CreateNetwork(m.ctx, name, "")
+		return NetworkActionMsg{Host: host, Network: name, Done: "Network " + name + " created", Err: err}
+	}
+}
+
+func (m Model) DeleteNetworkCmd(host, id, name string) tea.Cmd {
+	cli := m.cache.Client(host)
+	return func() tea.Msg {
+		err := cli.DeleteNetwork(m.ctx, id)
+		return NetworkActionMsg{Host: host, Network: name, Done: "Network " + name + " deleted", Err: err}
+	}
+}
+
+func (m Model) ConnectCmd(host, id, name, container string, aliases []string) tea.Cmd {
+	cli := m.cache.Client(host)
+	return func() tea.Msg {
+		var settings *network.EndpointSettings
+		if len(aliases) > 0 {
+			settings = &network.EndpointSettings{Aliases: aliases}
+		}
+		err := cli.ConnectNetwork(m.ctx, id, container, settings)
+		return NetworkActionMsg{Host: host, Network: name, Done: container + " connected to " + name, Err: err}
+	}
+}
+
+func (m Model) DisconnectCmd(host, id, name, container string) tea.Cmd {
+	cli := m.cache.Client(host)
+	return func() tea.Msg {
+		err := cli.DisconnectNetwork(m.ctx, id, container)
+		return NetworkActionMsg{Host: host, Network: name, Done: container + " disconnected from " + name, Err: err}
+	}
+}
@@ -0,0 +1,74 @@
+package networks
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kdruelle/gmd/docker/types"
+)
+
+// attachment is a container attached to a network.
+type attachment struct {
+	id      string
+	name    string
+	ip      string
+	aliases []string
+}
+
+func (a attachment) String() string {
+	s := a.name
+	if a.ip != "" {
+		s += " " + a.ip
+	}
+	if len(a.aliases) > 0 {
+		s += " (" + strings.Join(a.aliases, ", ") + ")"
+	}
+	return s
+}
+
+type NetworkItem struct {
+	types.Network
+	attached []attachment // attached are the containers attached to the network, sorted by name.
+}
+
+func (i NetworkItem) Title() string { return i.Name }
+func (i NetworkItem) Description() string {
+	addresses := make([]string, 0, len(i.IPAM.Config))
+	for _, config := range i.IPAM.Config {
+		switch {
+		case config.Subnet != "" && config.Gateway != "":
+			addresses = append(addresses, config.Subnet+" via "+config.Gateway)
+		case config.Subnet != "":
+			addresses = append(addresses, config.Subnet)
+		}
+	}
+	if len(addresses) == 0 {
+		addresses = append(addresses, "no subnet")
+	}
+	return fmt.Sprintf("%s - %s - %s", i.Driver, strings.Join(addresses, ", "), i.Scope)
+}
+
+// Containers returns the containers attached to the network, with their
+// IP and aliases.
+func (i NetworkItem) Containers() string {
+	if len(i.attached) == 0 {
+		return "no container"
+	}
+	out := make([]string, len(i.attached))
+	for j, a := range i.attached {
+		out[j] = a.String()
+	}
+	return strings.Join(out, " · ")
+}
+
+func (i NetworkItem) FilterValue() string { return i.Title() }
+
+// containerItem is a container offered by the container picker.
+type containerItem struct {
+	name string
+	desc string
+}
+
+func (i containerItem) Title() string       { return i.name }
+func (i containerItem) Description() string { return i.desc }
+func (i containerItem) FilterValue() string { return i.name }
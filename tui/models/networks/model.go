@@ -0,0 +1,463 @@
+package networks
+
+import (
+	"context"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/kdruelle/gmd/docker/cache"
+	"github.com/kdruelle/gmd/docker/types"
+	"github.com/kdruelle/gmd/tui/commands"
+	"github.com/kdruelle/gmd/tui/componants"
+	"github.com/kdruelle/gmd/tui/models/inspect"
+	style "github.com/kdruelle/gmd/tui/styles"
+)
+
+// networkKey identifies a network across hosts.
+type networkKey struct {
+	host string
+	id   string
+}
+
+// prompt is the question the text input answers.
+type prompt int
+
+const (
+	createPrompt prompt = iota
+	connectPrompt
+	disconnectPrompt
+)
+
+type Model struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	cache   *cache.Group
+	sub     <-chan cache.Change
+	list    list.Model
+	loaded  bool
+	unused  bool
+	status  string
+	confirm componants.Confirm
+	input   textinput.Model
+	picker  list.Model // picker lists the containers the connect and disconnect prompts apply to.
+	picking bool       // picking is true while a container is picked.
+	prompt  prompt
+	target  NetworkItem // target is the network the prompt applies to.
+	host    string      // host is the host the prompt applies to.
+	chosen  string      // chosen is the container picked for the connect prompt.
+}
+
+type listKeyMap struct {
+	toggleUnused key.Binding
+	create       key.Binding
+	inspect      key.Binding
+	delete       key.Binding
+	connect      key.Binding
+	disconnect   key.Binding
+}
+
+var keyMap = &listKeyMap{
+	toggleUnused: key.NewBinding(
+		key.WithKeys("u"),
+		key.WithHelp("u", "toggle unused only"),
+	),
+	create: key.NewBinding(
+		key.WithKeys("c"),
+		key.WithHelp("c", "create network"),
+	),
+	inspect: key.NewBinding(
+		key.WithKeys("i"),
+		key.WithHelp("i", "inspect network"),
+	),
+	delete: key.NewBinding(
+		key.WithKeys("d"),
+		key.WithHelp("d", "delete selection"),
+	),
+	connect: key.NewBinding(
+		key.WithKeys("a"),
+		key.WithHelp("a", "connect a container"),
+	),
+	disconnect: key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "disconnect a container"),
+	),
+}
+
+// changesFilter selects the changes the model is interested in. The
+// containers are watched too, since they carry their IPs and aliases.
+var changesFilter = cache.Filter{Types: []cache.EventType{cache.NetworkEventType, cache.ContainerEventType}}
+
+func New(cache *cache.Group) Model {
+
+	l := list.New([]list.Item{}, newItemDelegate(cache.MultiHost()), 0, 0)
+	l.Title = "Networks"
+	l.AdditionalFullHelpKeys = func() []key.Binding {
+		return []key.Binding{
+			keyMap.toggleUnused,
+			keyMap.create,
+			keyMap.inspect,
+			keyMap.delete,
+			keyMap.connect,
+			keyMap.disconnect,
+		}
+	}
+
+	input := textinput.New()
+	input.ShowSuggestions = true
+
+	picker := list.New([]list.Item{}, list.NewDefaultDelegate(), 0, 0)
+	picker.SetShowHelp(false)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return Model{
+		ctx:    ctx,
+		cancel: cancel,
+		cache:  cache,
+		sub:    cache.Subscribe(changesFilter),
+		list:   l,
+		input:  input,
+		picker: picker,
+	}
+}
+
+func (m Model) Init() tea.Cmd {
+	return commands.WaitChangeCmd(m.sub)
+}
+
+// Close cancels the pending commands of the model and ends its subscription.
+func (m Model) Close() {
+	m.cancel()
+	m.cache.Unsubscribe(m.sub)
+}
+
+func (m Model) IsSearching() bool {
+	return m.list.IsFiltered()
+}
+
+// IsFiltering reports whether the list filter is being typed.
+func (m Model) IsFiltering() bool {
+	return m.list.SettingFilter()
+}
+
+// IsPrompting reports whether the model waits for a name, for a container
+// to be picked or for a confirmation.
+func (m Model) IsPrompting() bool {
+	return m.input.Focused() || m.picking || m.confirm.Active()
+}
+
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+
+	switch msg := msg.(type) {
+
+	case tea.WindowSizeMsg:
+		m.list.SetSize(msg.Width, msg.Height-4)
+		m.picker.SetSize(msg.Width, msg.Height-4)
+		return m, nil
+
+	case cache.Event:
+		if msg.EventType == cache.NetworksLoadedEventType {
+			m.loaded = true
+			log.Printf("received networks loaded event: %+v", msg)
+			m.applyFilter()
+		}
+		return m, nil
+
+	case commands.ChangeMsg:
+		if msg.Sub != m.sub {
+			return m, nil
+		}
+		if m.loaded {
+			m.applyFilter()
+		}
+		return m, commands.WaitChangeCmd(m.sub)
+
+	case NetworkActionMsg:
+		if msg.Err != nil {
+			m.status = style.Danger().Render(msg.Err.Error())
+		} else {
+			m.status = style.Success().Render(msg.Done)
+		}
+		return m, nil
+
+	case tea.KeyMsg:
+		switch {
+		case m.input.Focused():
+			return m.updateInput(msg)
+		case m.picking:
+			return m.updatePicker(msg)
+		case m.confirm.Active():
+			m.status = ""
+			return m, m.confirm.Update(msg)
+		case m.list.SettingFilter():
+			break
+		case key.Matches(msg, keyMap.toggleUnused):
+			m.unused = !m.unused
+			m.applyFilter()
+			return m, nil
+		case key.Matches(msg, keyMap.create):
+			m.host = m.cache.Hosts()[0]
+			if n, ok := m.list.SelectedItem().(NetworkItem); ok {
+				m.host = n.Host
+			}
+			title := "Network name: "
+			if m.cache.MultiHost() {
+				title = "Network name on " + m.host + ": "
+			}
+			return m, m.ask(createPrompt, title, nil)
+		case key.Matches(msg, keyMap.inspect):
+			n, ok := m.list.SelectedItem().(NetworkItem)
+			if !ok {
+				return m, nil
+			}
+			return m, commands.SwitchPageCmd(func() tea.Model {
+				return inspect.New("Network "+n.Name, n.Network.Inspect)
+			})
+		case key.Matches(msg, keyMap.delete):
+			n, ok := m.list.SelectedItem().(NetworkItem)
+			if !ok {
+				return m, nil
+			}
+			if n.Predefined() {
+				m.status = style.Danger().Render("Network " + n.Name + " is predefined")
+				return m, nil
+			}
+			m.confirm.Ask("Delete network "+n.Name+"?", m.DeleteNetworkCmd(n.Host, n.ID, n.Name))
+			return m, nil
+		case key.Matches(msg, keyMap.connect):
+			n, ok := m.list.SelectedItem().(NetworkItem)
+			if !ok {
+				return m, nil
+			}
+			items := m.detached(n)
+			if len(items) == 0 {
+				m.status = style.Danger().Render("No container to connect to " + n.Name)
+				return m, nil
+			}
+			m.target, m.host = n, n.Host
+			m.pick(connectPrompt, "Connect a container to "+n.Name, items)
+			return m, nil
+		case key.Matches(msg, keyMap.disconnect):
+			n, ok := m.list.SelectedItem().(NetworkItem)
+			if !ok || len(n.attached) == 0 {
+				return m, nil
+			}
+			items := make([]list.Item, len(n.attached))
+			for i, a := range n.attached {
+				items[i] = containerItem{name: a.name, desc: strings.TrimPrefix(a.String(), a.name+" ")}
+			}
+			m.target, m.host = n, n.Host
+			m.pick(disconnectPrompt, "Disconnect a container from "+n.Name, items)
+			return m, nil
+		}
+	}
+
+	newList, cmd := m.list.Update(msg)
+	m.list = newList
+	return m, cmd
+}
+
+// ask focuses the text input to answer the given prompt, suggesting the
+// given values.
+func (m *Model) ask(p prompt, title string, suggestions []string) tea.Cmd {
+	m.prompt = p
+	m.input.Prompt = title
+	m.input.SetSuggestions(suggestions)
+	m.input.SetValue("")
+	return m.input.Focus()
+}
+
+// pick shows the container picker to answer the given prompt.
+func (m *Model) pick(p prompt, title string, items []list.Item) {
+	m.prompt = p
+	m.status = ""
+	m.picker.Title = title
+	m.picker.ResetFilter()
+	m.picker.SetItems(items)
+	m.picker.Select(0)
+	m.picking = true
+}
+
+// updatePicker handles the key strokes typed while a container is picked.
+// Enter connects the picked container, once its aliases are typed, or
+// disconnects it. Esc closes the picker.
+func (m Model) updatePicker(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.picker.SettingFilter() {
+		var cmd tea.Cmd
+		m.picker, cmd = m.picker.Update(msg)
+		return m, cmd
+	}
+
+	switch msg.String() {
+	case "enter":
+		c, ok := m.picker.SelectedItem().(containerItem)
+		if !ok {
+			return m, nil
+		}
+		m.picking = false
+		switch m.prompt {
+		case connectPrompt:
+			m.chosen = c.name
+			return m, m.ask(connectPrompt, "Aliases of "+c.name+" on "+m.target.Name+" (optional): ", nil)
+		case disconnectPrompt:
+			m.status = style.StatusBar().Render("Disconnecting " + c.name + " from " + m.target.Name)
+			return m, m.DisconnectCmd(m.host, m.target.ID, m.target.Name, c.name)
+		}
+		return m, nil
+	case "esc":
+		if m.picker.FilterState() != list.Unfiltered {
+			m.picker.ResetFilter()
+			return m, nil
+		}
+		m.picking = false
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.picker, cmd = m.picker.Update(msg)
+	return m, cmd
+}
+
+// updateInput handles the key strokes typed while a prompt is answered.
+func (m Model) updateInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter":
+		m.input.Blur()
+		fields := strings.Fields(m.input.Value())
+		switch m.prompt {
+		case createPrompt:
+			if len(fields) == 0 {
+				return m, nil
+			}
+			m.status = style.StatusBar().Render("Creating network " + fields[0])
+			return m, m.CreateNetworkCmd(m.host, fields[0])
+		case connectPrompt:
+			m.status = style.StatusBar().Render("Connecting " + m.chosen + " to " + m.target.Name)
+			return m, m.ConnectCmd(m.host, m.target.ID, m.target.Name, m.chosen, fields)
+		}
+		return m, nil
+	case "esc":
+		m.input.Blur()
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+// detached returns the containers of the network host that are not
+// attached to the network, sorted by name.
+func (m Model) detached(n NetworkItem) []list.Item {
+	var out []containerItem
+	for _, c := range m.cache.Containers() {
+		if c.Host != n.Host || slices.ContainsFunc(n.attached, func(a attachment) bool { return a.id == c.ID }) {
+			continue
+		}
+		item := containerItem{name: strings.TrimPrefix(c.Name, "/")}
+		if c.Config != nil && c.State != nil {
+			item.desc = c.Config.Image + " - " + c.State.Status
+		}
+		out = append(out, item)
+	}
+	slices.SortFunc(out, func(a, b containerItem) int { return strings.Compare(a.name, b.name) })
+
+	items := make([]list.Item, len(out))
+	for i, c := range out {
+		items[i] = c
+	}
+	return items
+}
+
+func (m Model) View() string {
+	if !m.loaded {
+		return "Chargement des réseaux Docker..."
+	}
+
+	if m.picking {
+		return lipgloss.JoinVertical(
+			lipgloss.Left,
+			m.picker.View(),
+			style.Subtitle().Render("enter: select · /: filter · esc: cancel"),
+		)
+	}
+
+	bottom := m.status
+	switch {
+	case m.input.Focused():
+		bottom = m.input.View()
+	case m.confirm.Active():
+		bottom = m.confirm.View()
+	}
+
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		m.list.View(),
+		bottom,
+	)
+}
+
+// applyFilter fills the list with the networks of the cache, or with the
+// unused ones only, along with the containers attached to them.
+//
+// The attached containers come from the network, which only lists the
+// running ones, completed by the stopped containers configured on the
+// network. The aliases only come from the containers.
+func (m *Model) applyFilter() {
+	var networks []types.Network
+	if m.unused {
+		networks = m.cache.NetworksUnused()
+	} else {
+		networks = m.cache.Networks()
+	}
+
+	endpoints := make(map[networkKey]map[string]attachment)
+	for _, c := range m.cache.Containers() {
+		if c.NetworkSettings == nil {
+			continue
+		}
+		for _, endpoint := range c.NetworkSettings.Networks {
+			if endpoint == nil {
+				continue
+			}
+			key := networkKey{host: c.Host, id: endpoint.NetworkID}
+			if endpoints[key] == nil {
+				endpoints[key] = make(map[string]attachment)
+			}
+			endpoints[key][c.ID] = attachment{
+				id:      c.ID,
+				name:    strings.TrimPrefix(c.Name, "/"),
+				ip:      endpoint.IPAddress,
+				aliases: endpoint.Aliases,
+			}
+		}
+	}
+
+	items := make([]list.Item, 0, len(networks))
+	for _, n := range networks {
+		configured := endpoints[networkKey{host: n.Host, id: n.ID}]
+		a := make([]attachment, 0, max(len(n.Containers), len(configured)))
+		for id, endpoint := range n.Containers {
+			ip, _, _ := strings.Cut(endpoint.IPv4Address, "/")
+			a = append(a, attachment{
+				id:      id,
+				name:    endpoint.Name,
+				ip:      ip,
+				aliases: configured[id].aliases,
+			})
+		}
+		for id, c := range configured {
+			if _, ok := n.Containers[id]; !ok {
+				a = append(a, c)
+			}
+		}
+		slices.SortFunc(a, func(x, y attachment) int { return strings.Compare(x.name, y.name) })
+		items = append(items, NetworkItem{Network: n, attached: a})
+	}
+	m.list.SetItems(items)
+}
@@ -0,0 +1,21 @@
+package updatehistory
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kdruelle/gmd/history"
+)
+
+// HistoryLoadedMsg carries the entries of the history file.
+type HistoryLoadedMsg struct {
+	Entries []history.Entry
+	Err     error
+}
+
+// loadHistoryCmd returns a command reading the history file at the given
+// path.
+func loadHistoryCmd(path string) tea.Cmd {
+	return func() tea.Msg {
+		entries, err := history.Load(path)
+		return HistoryLoadedMsg{Entries: entries, Err: err}
+	}
+}
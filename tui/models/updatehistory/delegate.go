@@ -0,0 +1,57 @@
+package updatehistory
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/kdruelle/gmd/tui/controllers/containerupdate"
+	style "github.com/kdruelle/gmd/tui/styles"
+)
+
+type ItemDelegate struct {
+	list.DefaultDelegate
+}
+
+func newItemDelegate() list.ItemDelegate {
+	d := list.NewDefaultDelegate()
+	return ItemDelegate{d}
+}
+
+func (d ItemDelegate) Height() int  { return 2 }
+func (d ItemDelegate) Spacing() int { return 0 }
+func (d ItemDelegate) Update(msg tea.Msg, m *list.Model) tea.Cmd {
+	return nil
+}
+
+func (d ItemDelegate) Render(w io.Writer, m list.Model, index int, item list.Item) {
+	h, ok := item.(HistoryItem)
+	if !ok {
+		return
+	}
+
+	title := lipgloss.JoinHorizontal(lipgloss.Left, style.Title().Render(h.Title()), "  ", resultStyle(h.entry.Result).Render(h.entry.Result))
+	desc := style.Subtitle().Render(h.Description())
+
+	content := lipgloss.JoinVertical(lipgloss.Left, title, desc)
+
+	if index == m.Index() {
+		content = style.ListSelectedLine().Inherit(style.Bold()).Render(content)
+	}
+
+	fmt.Fprint(w, lipgloss.JoinHorizontal(lipgloss.Center, content, " "))
+}
+
+// resultStyle returns the style of the given result of an update.
+func resultStyle(result string) lipgloss.Style {
+	switch result {
+	case containerupdate.ResultSucceeded.String():
+		return style.Success()
+	case containerupdate.ResultRolledBack.String(), containerupdate.ResultCanceled.String():
+		return style.Warning()
+	default:
+		return style.Danger()
+	}
+}
@@ -0,0 +1,32 @@
+package updatehistory
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kdruelle/gmd/history"
+)
+
+type HistoryItem struct {
+	entry    history.Entry
+	showHost bool
+}
+
+func (i HistoryItem) Title() string {
+	if i.showHost {
+		return i.entry.Container + " @ " + i.entry.Host
+	}
+	return i.entry.Container
+}
+
+func (i HistoryItem) Description() string {
+	return fmt.Sprintf("%s  %s  %s → %s  by %s",
+		i.entry.Started.Local().Format(time.DateTime),
+		i.entry.Image(),
+		i.entry.Old.Short(),
+		i.entry.New.Short(),
+		i.entry.Operator,
+	)
+}
+
+func (i HistoryItem) FilterValue() string { return i.entry.Container }
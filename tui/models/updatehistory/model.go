@@ -0,0 +1,194 @@
+// Package updatehistory provides a screen listing the container updates
+// recorded in the history file, newest first, with the details and steps of
+// the selected update.
+package updatehistory
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/kdruelle/gmd/history"
+	"github.com/kdruelle/gmd/tui/commands"
+	style "github.com/kdruelle/gmd/tui/styles"
+)
+
+// Model is the History screen.
+type Model struct {
+	path     string
+	showHost bool
+	list     list.Model
+	viewport viewport.Model
+	details  bool // details is true while the details of the selected update are shown.
+	loaded   bool
+	status   string
+}
+
+type listKeyMap struct {
+	details key.Binding
+	back    key.Binding
+}
+
+var keyMap = &listKeyMap{
+	details: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "show the steps of the update"),
+	),
+	back: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "get back"),
+	),
+}
+
+// New returns the History screen of the history file at the given path.
+// showHost shows the host of the containers, when gmd watches several.
+func New(path string, showHost bool) Model {
+	l := list.New([]list.Item{}, newItemDelegate(), 0, 0)
+	l.Title = "Update history"
+	l.AdditionalFullHelpKeys = func() []key.Binding {
+		return []key.Binding{
+			keyMap.details,
+			keyMap.back,
+		}
+	}
+
+	return Model{
+		path:     path,
+		showHost: showHost,
+		list:     l,
+		viewport: viewport.New(0, 0),
+	}
+}
+
+func (m Model) Init() tea.Cmd {
+	return loadHistoryCmd(m.path)
+}
+
+func (m Model) IsSearching() bool {
+	return m.list.IsFiltered()
+}
+
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+
+	switch msg := msg.(type) {
+
+	case tea.WindowSizeMsg:
+		m.list.SetSize(msg.Width, msg.Height-2)
+		m.viewport.Width = msg.Width
+		m.viewport.Height = msg.Height - 3
+		return m, nil
+
+	case HistoryLoadedMsg:
+		m.loaded = true
+		if msg.Err != nil {
+			m.status = style.Danger().Render(msg.Err.Error())
+		}
+		items := make([]list.Item, 0, len(msg.Entries))
+		for _, e := range slices.Backward(msg.Entries) {
+			items = append(items, HistoryItem{entry: e, showHost: m.showHost})
+		}
+		m.list.SetItems(items)
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.details {
+			if key.Matches(msg, keyMap.back) {
+				m.details = false
+				return m, nil
+			}
+			var cmd tea.Cmd
+			m.viewport, cmd = m.viewport.Update(msg)
+			return m, cmd
+		}
+		if m.list.SettingFilter() {
+			break
+		}
+		switch {
+		case key.Matches(msg, keyMap.back) && m.list.FilterState() == list.Unfiltered:
+			return m, commands.SwitchPageCmd(nil)
+
+		case key.Matches(msg, keyMap.details):
+			item, ok := m.list.SelectedItem().(HistoryItem)
+			if !ok {
+				return m, nil
+			}
+			m.details = true
+			m.viewport.SetContent(render(item.entry))
+			m.viewport.GotoTop()
+			return m, nil
+		}
+	}
+
+	newList, cmd := m.list.Update(msg)
+	m.list = newList
+	return m, cmd
+}
+
+func (m Model) View() string {
+	if !m.loaded {
+		return "Loading history..."
+	}
+	if m.details {
+		item, _ := m.list.SelectedItem().(HistoryItem)
+		return lipgloss.JoinVertical(
+			lipgloss.Left,
+			style.Title().Render("Update of "+item.Title()),
+			m.viewport.View(),
+			style.Inactive().Render(keyMap.back.Help().Key+" "+keyMap.back.Help().Desc),
+		)
+	}
+	if len(m.list.Items()) == 0 && m.status == "" {
+		return lipgloss.JoinVertical(lipgloss.Left,
+			style.Title().Render("Update history"),
+			style.Inactive().Render("No update recorded in "+m.path),
+		)
+	}
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		m.list.View(),
+		m.status,
+	)
+}
+
+// render returns the details of the given update.
+func render(e history.Entry) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "  result    %s\n", resultStyle(e.Result).Render(e.Result))
+	if e.Error != "" {
+		fmt.Fprintf(&b, "  error     %s\n", style.Danger().Render(e.Error))
+	}
+	fmt.Fprintf(&b, "  host      %s\n", e.Host)
+	fmt.Fprintf(&b, "  operator  %s\n", e.Operator)
+	fmt.Fprintf(&b, "  started   %s\n", e.Started.Local().Format(time.DateTime))
+	fmt.Fprintf(&b, "  ended     %s (%s)\n", e.Ended.Local().Format(time.DateTime), e.Ended.Sub(e.Started).Round(time.Second))
+
+	b.WriteString("\n" + style.Bold().Render("Image") + "\n")
+	fmt.Fprintf(&b, "  old  %s\n", imageLine(e.Old))
+	fmt.Fprintf(&b, "  new  %s\n", imageLine(e.New))
+
+	b.WriteString("\n" + style.Bold().Render("Steps") + "\n")
+	for _, step := range e.Steps {
+		fmt.Fprintf(&b, "  %s\n", step)
+	}
+
+	return b.String()
+}
+
+// imageLine returns the reference, digest and ID of the given image.
+func imageLine(img history.Image) string {
+	if img.Ref == "" && img.ID == "" {
+		return style.Inactive().Render("not pulled")
+	}
+	digest := img.Digest
+	if digest == "" {
+		digest = "no digest"
+	}
+	return fmt.Sprintf("%s  %s  %s", img.Ref, digest, style.Subtitle().Render(img.ID))
+}
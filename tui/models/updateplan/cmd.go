@@ -0,0 +1,25 @@
+package updateplan
+
+import (
+	"context"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kdruelle/gmd/docker/client"
+	"github.com/kdruelle/gmd/docker/types"
+	"github.com/kdruelle/gmd/tui/controllers/containerupdate"
+)
+
+// PlanMsg carries the plan of an update.
+type PlanMsg struct {
+	Plan containerupdate.Plan
+	Err  error
+}
+
+// planCmd returns a command computing the plan of the update of the given
+// container with the given options.
+func planCmd(ctx context.Context, cli *client.Client, c types.Container, opts containerupdate.Options) tea.Cmd {
+	return func() tea.Msg {
+		plan, err := containerupdate.NewPlan(ctx, cli, c, opts)
+		return PlanMsg{Plan: plan, Err: err}
+	}
+}
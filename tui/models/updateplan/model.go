@@ -0,0 +1,177 @@
+// Package updateplan provides a screen showing what updating a container
+// would do, without doing it: the steps of the update, the current and new
+// images, and how the configuration of the container would change.
+package updateplan
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/kdruelle/gmd/docker/client"
+	"github.com/kdruelle/gmd/docker/types"
+	"github.com/kdruelle/gmd/tui/commands"
+	"github.com/kdruelle/gmd/tui/controllers/containerupdate"
+	updatemodel "github.com/kdruelle/gmd/tui/models/containerupdate"
+	style "github.com/kdruelle/gmd/tui/styles"
+)
+
+type Model struct {
+	ctx       context.Context
+	cancel    context.CancelFunc
+	container types.Container
+	cli       *client.Client
+	opts      containerupdate.Options
+	viewport  viewport.Model
+	loaded    bool
+	content   string
+}
+
+type listKeyMap struct {
+	back   key.Binding
+	update key.Binding
+}
+
+var keyMap = &listKeyMap{
+	back: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "get back"),
+	),
+	update: key.NewBinding(
+		key.WithKeys("u"),
+		key.WithHelp("u", "run the update"),
+	),
+}
+
+// New returns a screen showing the plan of the update of the given container.
+func New(c types.Container, cli *client.Client, opts containerupdate.Options) Model {
+	ctx, cancel := context.WithCancel(context.Background())
+	return Model{
+		ctx:       ctx,
+		cancel:    cancel,
+		container: c,
+		cli:       cli,
+		opts:      opts,
+		viewport:  viewport.New(0, 0),
+	}
+}
+
+func (m Model) Init() tea.Cmd {
+	return planCmd(m.ctx, m.cli, m.container, m.opts)
+}
+
+// Close cancels the computation of the plan.
+func (m Model) Close() {
+	m.cancel()
+}
+
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.viewport.Width = msg.Width
+		m.viewport.Height = msg.Height - 3
+		return m, nil
+	case PlanMsg:
+		m.loaded = true
+		if msg.Err != nil {
+			m.content = style.Danger().Render(msg.Err.Error())
+		} else {
+			m.content = render(msg.Plan)
+		}
+		m.viewport.SetContent(m.content)
+		return m, nil
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, keyMap.back):
+			return m, commands.SwitchPageCmd(nil)
+		case key.Matches(msg, keyMap.update):
+			c, cli, opts := m.container, m.cli, m.opts
+			return m, tea.Sequence(
+				commands.SwitchPageCmd(nil),
+				commands.SwitchPageCmd(func() tea.Model {
+					return updatemodel.New(c, cli, opts)
+				}),
+			)
+		}
+	}
+
+	var cmd tea.Cmd
+	m.viewport, cmd = m.viewport.Update(msg)
+	return m, cmd
+}
+
+func (m Model) View() string {
+	title := style.Title().Render("Update plan of " + strings.TrimPrefix(m.container.Name, "/"))
+	if !m.loaded {
+		return lipgloss.JoinVertical(lipgloss.Left, title, "Calcul du plan de mise à jour...")
+	}
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		title,
+		m.viewport.View(),
+		style.Inactive().Render(keyMap.update.Help().Key+" "+keyMap.update.Help().Desc+" • "+keyMap.back.Help().Key+" "+keyMap.back.Help().Desc),
+	)
+}
+
+// render returns the content of the screen for the given plan.
+func render(p containerupdate.Plan) string {
+	var b strings.Builder
+
+	b.WriteString(style.Bold().Render("Steps") + "\n")
+	for i, step := range p.Steps {
+		fmt.Fprintf(&b, "  %d. %s\n", i+1, step)
+	}
+
+	b.WriteString("\n" + style.Bold().Render("Image") + "\n")
+	fmt.Fprintf(&b, "  current  %s\n", imageLine(p.Current))
+	if p.TargetErr != nil {
+		fmt.Fprintf(&b, "  new      %s\n", style.Danger().Render(p.TargetErr.Error()))
+	} else {
+		target := imageLine(p.Target)
+		if p.Target.Digest == p.Current.Digest {
+			target += " " + style.Success().Render("(up to date)")
+		}
+		fmt.Fprintf(&b, "  new      %s\n", target)
+	}
+
+	b.WriteString("\n" + style.Bold().Render("Configuration") + "\n")
+	switch {
+	case p.Compose != nil:
+		fmt.Fprintf(&b, "  created by docker compose from %s\n", strings.Join(p.Compose.ConfigFiles, ", "))
+	case len(p.Changes) == 0:
+		b.WriteString("  no change\n")
+	default:
+		for _, c := range p.Changes {
+			fmt.Fprintf(&b, "  %s\n", style.Subtitle().Render(c.Path))
+			fmt.Fprintf(&b, "    %s\n", style.Danger().Render("- "+valueOrUnset(c.Old)))
+			fmt.Fprintf(&b, "    %s\n", style.Success().Render("+ "+valueOrUnset(c.New)))
+		}
+	}
+
+	return b.String()
+}
+
+// imageLine returns the digest and creation date of the given image.
+func imageLine(img client.ImageInfo) string {
+	digest := img.Digest
+	if digest == "" {
+		digest = "no digest"
+	}
+	created := "-"
+	if !img.Created.IsZero() {
+		created = img.Created.Local().Format(time.DateTime)
+	}
+	return fmt.Sprintf("%s  %s", digest, style.Subtitle().Render("created "+created))
+}
+
+func valueOrUnset(v string) string {
+	if v == "" {
+		return "(unset)"
+	}
+	return v
+}
@@ -0,0 +1,55 @@
+package volumes
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type VolumesSizeMsg struct {
+	Host  string
+	Sizes map[string]int64
+	Err   error
+}
+
+type CreateVolumeMsg struct {
+	Host string
+	Name string
+	Err  error
+}
+
+type DeleteVolumeMsg struct {
+	Host string
+	Name string
+	Err  error
+}
+
+// VolumesSizeCmd reads the size of the volumes of every host.
+func (m Model) VolumesSizeCmd() tea.Cmd {
+	var cmds []tea.Cmd
+	for _, host := range m.cache.Hosts() {
+		cli := m.cache.Client(host)
+		cmds = append(cmds, func() tea.Msg {
+			sizes, err := cli.VolumesSize(m.ctx)
+			return VolumesSizeMsg{Host: host, Sizes: sizes, Err: err}
+		})
+	}
+	return tea.Batch(cmds...)
+}
+
+func (m Model) CreateVolumeCmd(host, name string) tea.Cmd {
+	cli := m.cache.Client(host)
+	return func() tea.Msg {
+		v, err := cli.CreateVolume(m.ctx, name, "")
+		if err != nil {
+			return CreateVolumeMsg{Host: host, Name: name, Err: err}
+		}
+		return CreateVolumeMsg{Host: host, Name: v.Name}
+	}
+}
+
+func (m Model) DeleteVolumeCmd(host, name string) tea.Cmd {
+	cli := m.cache.Client(host)
+	return func() tea.Msg {
+		err := cli.DeleteVolume(m.ctx, name)
+		return DeleteVolumeMsg{Host: host, Name: name, Err: err}
+	}
+}
@@ -0,0 +1,29 @@
+package volumes
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/kdruelle/gmd/docker/types"
+)
+
+type VolumeItem struct {
+	types.Volume
+	size   int64    // size is the size of the volume on disk, -1 if unknown.
+	usedBy []string // usedBy are the names of the containers mounting the volume.
+}
+
+func (i VolumeItem) Title() string { return i.Name }
+func (i VolumeItem) Description() string {
+	size := "?"
+	if i.size >= 0 {
+		size = humanize.Bytes(uint64(i.size))
+	}
+	usage := "unused"
+	if len(i.usedBy) > 0 {
+		usage = "used by " + strings.Join(i.usedBy, ", ")
+	}
+	return fmt.Sprintf("%s - %s - %s - %s", i.Driver, i.Mountpoint, size, usage)
+}
+func (i VolumeItem) FilterValue() string { return i.Title() }
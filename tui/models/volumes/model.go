@@ -0,0 +1,305 @@
+package volumes
+
+import (
+	"context"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/kdruelle/gmd/docker/cache"
+	"github.com/kdruelle/gmd/docker/types"
+	"github.com/kdruelle/gmd/tui/commands"
+	"github.com/kdruelle/gmd/tui/componants"
+	"github.com/kdruelle/gmd/tui/models/inspect"
+	style "github.com/kdruelle/gmd/tui/styles"
+)
+
+// volumeKey identifies a volume across hosts.
+type volumeKey struct {
+	host string
+	name string
+}
+
+type Model struct {
+	ctx        context.Context
+	cancel     context.CancelFunc
+	cache      *cache.Group
+	sub        <-chan cache.Change
+	list       list.Model
+	loaded     bool
+	unused     bool
+	sizes      map[volumeKey]int64
+	status     string
+	confirm    componants.Confirm
+	input      textinput.Model
+	createHost string
+}
+
+type listKeyMap struct {
+	toggleUnused key.Binding
+	create       key.Binding
+	inspect      key.Binding
+	delete       key.Binding
+	refresh      key.Binding
+}
+
+var keyMap = &listKeyMap{
+	toggleUnused: key.NewBinding(
+		key.WithKeys("u"),
+		key.WithHelp("u", "toggle unused only"),
+	),
+	create: key.NewBinding(
+		key.WithKeys("c"),
+		key.WithHelp("c", "create volume"),
+	),
+	inspect: key.NewBinding(
+		key.WithKeys("i"),
+		key.WithHelp("i", "inspect volume"),
+	),
+	delete: key.NewBinding(
+		key.WithKeys("d"),
+		key.WithHelp("d", "delete selection"),
+	),
+	refresh: key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "refresh sizes"),
+	),
+}
+
+// changesFilter selects the changes the model is interested in. The
+// containers are watched too, since they make volumes used.
+var changesFilter = cache.Filter{Types: []cache.EventType{cache.VolumeEventType, cache.ContainerEventType}}
+
+func New(cache *cache.Group) Model {
+
+	l := list.New([]list.Item{}, newItemDelegate(cache.MultiHost()), 0, 0)
+	l.Title = "Volumes"
+	l.AdditionalFullHelpKeys = func() []key.Binding {
+		return []key.Binding{
+			keyMap.toggleUnused,
+			keyMap.create,
+			keyMap.inspect,
+			keyMap.delete,
+			keyMap.refresh,
+		}
+	}
+
+	input := textinput.New()
+	input.Placeholder = "empty for a generated name"
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return Model{
+		ctx:    ctx,
+		cancel: cancel,
+		cache:  cache,
+		sub:    cache.Subscribe(changesFilter),
+		list:   l,
+		sizes:  make(map[volumeKey]int64),
+		input:  input,
+	}
+}
+
+func (m Model) Init() tea.Cmd {
+	return commands.WaitChangeCmd(m.sub)
+}
+
+// Close cancels the pending commands of the model and ends its subscription.
+func (m Model) Close() {
+	m.cancel()
+	m.cache.Unsubscribe(m.sub)
+}
+
+func (m Model) IsSearching() bool {
+	return m.list.IsFiltered()
+}
+
+// IsFiltering reports whether the list filter is being typed.
+func (m Model) IsFiltering() bool {
+	return m.list.SettingFilter()
+}
+
+// IsPrompting reports whether the model waits for a volume name or for a
+// confirmation.
+func (m Model) IsPrompting() bool {
+	return m.input.Focused() || m.confirm.Active()
+}
+
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+
+	switch msg := msg.(type) {
+
+	case tea.WindowSizeMsg:
+		m.list.SetSize(msg.Width, msg.Height-4)
+		return m, nil
+
+	case cache.Event:
+		if msg.EventType == cache.VolumesLoadedEventType {
+			m.loaded = true
+			log.Printf("received volumes loaded event: %+v", msg)
+			m.applyFilter()
+			return m, m.VolumesSizeCmd()
+		}
+
+	case commands.ChangeMsg:
+		if msg.Sub != m.sub {
+			return m, nil
+		}
+		if m.loaded {
+			m.applyFilter()
+		}
+		return m, commands.WaitChangeCmd(m.sub)
+
+	case VolumesSizeMsg:
+		if msg.Err != nil {
+			m.status = style.Danger().Render(msg.Host + ": " + msg.Err.Error())
+			return m, nil
+		}
+		for name, size := range msg.Sizes {
+			m.sizes[volumeKey{host: msg.Host, name: name}] = size
+		}
+		m.applyFilter()
+		return m, nil
+
+	case CreateVolumeMsg:
+		if msg.Err != nil {
+			m.status = style.Danger().Render(msg.Err.Error())
+		} else {
+			m.status = style.Success().Render("Volume " + msg.Name + " created")
+		}
+		return m, nil
+
+	case DeleteVolumeMsg:
+		if msg.Err != nil {
+			m.status = style.Danger().Render(msg.Err.Error())
+		} else {
+			m.status = style.Success().Render("Volume deleted")
+		}
+		return m, nil
+
+	case tea.KeyMsg:
+		switch {
+		case m.input.Focused():
+			return m.updateInput(msg)
+		case m.confirm.Active():
+			m.status = ""
+			return m, m.confirm.Update(msg)
+		case m.list.SettingFilter():
+			break
+		case key.Matches(msg, keyMap.toggleUnused):
+			m.unused = !m.unused
+			m.applyFilter()
+			return m, nil
+		case key.Matches(msg, keyMap.create):
+			m.createHost = m.cache.Hosts()[0]
+			if v, ok := m.list.SelectedItem().(VolumeItem); ok {
+				m.createHost = v.Host
+			}
+			m.input.Prompt = "Volume name: "
+			if m.cache.MultiHost() {
+				m.input.Prompt = "Volume name on " + m.createHost + ": "
+			}
+			m.input.SetValue("")
+			return m, m.input.Focus()
+		case key.Matches(msg, keyMap.inspect):
+			v, ok := m.list.SelectedItem().(VolumeItem)
+			if !ok {
+				return m, nil
+			}
+			return m, commands.SwitchPageCmd(func() tea.Model {
+				return inspect.New("Volume "+v.Name, v.Volume.Volume)
+			})
+		case key.Matches(msg, keyMap.delete):
+			v, ok := m.list.SelectedItem().(VolumeItem)
+			if !ok {
+				return m, nil
+			}
+			m.confirm.Ask("Delete volume "+v.Name+"?", m.DeleteVolumeCmd(v.Host, v.Name))
+			return m, nil
+		case key.Matches(msg, keyMap.refresh):
+			return m, m.VolumesSizeCmd()
+		}
+	}
+
+	newList, cmd := m.list.Update(msg)
+	m.list = newList
+	return m, cmd
+}
+
+// updateInput handles the key strokes typed while the volume name is read.
+func (m Model) updateInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter":
+		m.input.Blur()
+		name := strings.TrimSpace(m.input.Value())
+		m.status = style.StatusBar().Render("Creating volume " + name)
+		return m, m.CreateVolumeCmd(m.createHost, name)
+	case "esc":
+		m.input.Blur()
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+func (m Model) View() string {
+	if !m.loaded {
+		return "Chargement des volumes Docker..."
+	}
+
+	bottom := m.status
+	switch {
+	case m.input.Focused():
+		bottom = m.input.View()
+	case m.confirm.Active():
+		bottom = m.confirm.View()
+	}
+
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		m.list.View(),
+		bottom,
+	)
+}
+
+// applyFilter fills the list with the volumes of the cache, or with the
+// unused ones only, along with their size and the containers mounting them.
+func (m *Model) applyFilter() {
+	var volumes []types.Volume
+	if m.unused {
+		volumes = m.cache.VolumesUnused()
+	} else {
+		volumes = m.cache.Volumes()
+	}
+
+	usedBy := make(map[volumeKey][]string)
+	for _, c := range m.cache.Containers() {
+		for _, mnt := range c.Mounts {
+			if mnt.Type != mount.TypeVolume || mnt.Name == "" {
+				continue
+			}
+			key := volumeKey{host: c.Host, name: mnt.Name}
+			usedBy[key] = append(usedBy[key], strings.TrimPrefix(c.Name, "/"))
+		}
+	}
+
+	items := make([]list.Item, 0, len(volumes))
+	for _, v := range volumes {
+		key := volumeKey{host: v.Host, name: v.Name}
+		size, ok := m.sizes[key]
+		if !ok {
+			size = -1
+		}
+		names := usedBy[key]
+		slices.Sort(names)
+		items = append(items, VolumeItem{Volume: v, size: size, usedBy: names})
+	}
+	m.list.SetItems(items)
+}
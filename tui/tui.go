@@ -5,9 +5,11 @@ import (
 	"log"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kdruelle/gmd/docker/client"
+	"github.com/kdruelle/gmd/tui/controllers/containerupdate"
 )
 
-func Start(debugFile string) (err error) {
+func Start(debugFile string, opts client.Options, updateOpts containerupdate.Options) (err error) {
 
 	if debugFile != "" {
 		f, err := tea.LogToFile(debugFile, "debug")
@@ -20,7 +22,7 @@ func Start(debugFile string) (err error) {
 		log.SetOutput(io.Discard)
 	}
 
-	model, err := NewModel()
+	model, err := NewModel(opts, updateOpts)
 
 	if err != nil {
 		return err
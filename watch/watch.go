@@ -0,0 +1,237 @@
+// Package watch runs gmd without its TUI: on a schedule, it checks every
+// cached container for an update, and updates the outdated containers whose
+// update policy is auto through the update pipeline of the TUI. Available
+// updates and failed updates are notified.
+package watch
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alitto/pond/v2"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/kdruelle/gmd/docker/cache"
+	"github.com/kdruelle/gmd/docker/types"
+	"github.com/kdruelle/gmd/notify"
+	"github.com/kdruelle/gmd/tui/controllers/containerupdate"
+	"github.com/robfig/cron/v3"
+)
+
+// DefaultSchedule is the schedule of the checks when none is configured:
+// every day at 4am.
+const DefaultSchedule = "0 4 * * *"
+
+// checkWorkers bounds the number of update checks made at once.
+const checkWorkers = 4
+
+// Options configures a Watcher.
+type Options struct {
+	Schedule string                  // Schedule is a cron expression (minute hour dom month dow) or a descriptor such as @hourly or @every 30m.
+	Now      bool                    // Now runs a check at start-up, before the first scheduled one.
+	Update   containerupdate.Options // Update configures the updates.
+	Notifier notify.Notifier         // Notifier is notified of the available updates and of the failed updates, nil for none.
+}
+
+// Watcher checks the containers of a cache for updates on a schedule.
+type Watcher struct {
+	group    *cache.Group
+	schedule cron.Schedule
+	opts     Options
+	logger   *slog.Logger
+}
+
+// New returns a watcher of the containers of the given cache, logging to the
+// given logger.
+// It returns an error if the schedule could not be parsed.
+func New(group *cache.Group, opts Options, logger *slog.Logger) (*Watcher, error) {
+	if opts.Schedule == "" {
+		opts.Schedule = DefaultSchedule
+	}
+	schedule, err := cron.ParseStandard(opts.Schedule)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: %w", opts.Schedule, err)
+	}
+	return &Watcher{
+		group:    group,
+		schedule: schedule,
+		opts:     opts,
+		logger:   logger,
+	}, nil
+}
+
+// Run loads the cache and runs the checks on schedule until ctx is canceled.
+// A check running at that time skips the updates that did not start yet, and
+// Run waits for the running ones to end before closing the cache.
+// It returns an error if no host could be loaded.
+func (w *Watcher) Run(ctx context.Context) error {
+	defer w.group.Close()
+
+	// the cache blocks until its events are read.
+	go func() {
+		for {
+			select {
+			case <-w.group.Events():
+			case <-w.group.Done():
+				return
+			}
+		}
+	}()
+
+	if err := w.group.LoadAndStart(); err != nil {
+		if len(w.group.Containers()) == 0 {
+			return err
+		}
+		w.logger.Warn("some hosts could not be loaded", "err", err)
+	}
+	w.logger.Info("cache loaded", "hosts", w.group.Hosts(), "containers", len(w.group.Containers()))
+
+	logger := cronLogger{w.logger}
+	c := cron.New(cron.WithLogger(logger), cron.WithChain(cron.SkipIfStillRunning(logger)))
+	c.Schedule(w.schedule, cron.FuncJob(func() { w.Check(ctx) }))
+
+	if w.opts.Now {
+		w.Check(ctx)
+	}
+
+	c.Start()
+	w.logger.Info("watching", "schedule", w.opts.Schedule, "next", w.schedule.Next(time.Now()))
+
+	<-ctx.Done()
+	w.logger.Info("stopping, waiting for running updates")
+	<-c.Stop().Done()
+	w.logger.Info("stopped")
+	return nil
+}
+
+// Check checks every cached container for an update, then updates the
+// outdated containers whose update policy is auto, in a batch.
+// Canceling ctx stops the checks and skips the updates that did not start.
+func (w *Watcher) Check(ctx context.Context) {
+	start := time.Now()
+	w.logger.Info("checking for updates")
+
+	var (
+		mu       sync.Mutex
+		checked  int
+		outdated []types.Container
+	)
+
+	pool := pond.NewPool(checkWorkers, pond.WithContext(ctx))
+	group := pool.NewGroup()
+	for _, c := range w.group.Containers() {
+		policy := c.UpdatePolicy()
+		if policy == types.UpdatePolicySkip {
+			continue
+		}
+		logger := w.logger.With("host", c.Host, "container", strings.TrimPrefix(c.Name, "/"), "image", c.UpdateImage(), "policy", string(policy))
+
+		group.Submit(func() {
+			digest, err := w.group.Client(c.Host).UpdateDigest(ctx, c.ID)
+			if err == nil && digest != "" {
+				w.notify(ctx, notify.Event{
+					Kind:      notify.KindUpdateAvailable,
+					Host:      c.Host,
+					Container: strings.TrimPrefix(c.Name, "/"),
+					Image:     c.UpdateImage(),
+					Digest:    digest,
+				})
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			checked++
+
+			switch {
+			case err != nil:
+				logger.Warn("update check failed", "err", err)
+			case digest == "":
+				logger.Debug("up to date")
+			case policy == types.UpdatePolicyAuto:
+				logger.Info("update available, updating", "digest", digest)
+				outdated = append(outdated, c)
+			default:
+				logger.Info("update available", "digest", digest)
+			}
+		})
+	}
+	_ = group.Wait()
+	pool.StopAndWait()
+
+	w.logger.Info("check done", "checked", checked, "updating", len(outdated), "duration", time.Since(start).Round(time.Millisecond))
+	if len(outdated) == 0 || ctx.Err() != nil {
+		return
+	}
+
+	w.update(ctx, outdated)
+}
+
+// update updates the given containers in a batch and logs the result of
+// every update.
+func (w *Watcher) update(ctx context.Context, containers []types.Container) {
+	start := time.Now()
+
+	batch := containerupdate.NewBatch(containers, w.group.Client, w.opts.Update)
+	batch.Start(ctx)
+	for range batch.Events() {
+	}
+
+	counts := make(map[containerupdate.Result]int)
+	// the statuses are in the order of the containers.
+	for i, s := range batch.Status() {
+		counts[s.Result]++
+
+		logger := w.logger.With("host", s.Host, "container", s.Name, "result", s.Result.String())
+		line := ansi.Strip(s.Line)
+		event := notify.Event{Host: s.Host, Container: s.Name, Image: containers[i].UpdateImage(), Message: line}
+		switch s.Result {
+		case containerupdate.ResultSucceeded:
+			logger.Info("container updated")
+		case containerupdate.ResultRolledBack:
+			logger.Warn("update rolled back", "reason", line)
+			event.Kind = notify.KindRolledBack
+			w.notify(context.WithoutCancel(ctx), event)
+		case containerupdate.ResultSkipped, containerupdate.ResultCanceled:
+			logger.Warn("update not run", "reason", line)
+		default:
+			logger.Error("update failed", "reason", line)
+			event.Kind = notify.KindUpdateFailed
+			w.notify(context.WithoutCancel(ctx), event)
+		}
+	}
+
+	w.logger.Info("updates done",
+		"updated", counts[containerupdate.ResultSucceeded],
+		"failed", counts[containerupdate.ResultFailed],
+		"rolled_back", counts[containerupdate.ResultRolledBack],
+		"skipped", counts[containerupdate.ResultSkipped]+counts[containerupdate.ResultCanceled],
+		"duration", time.Since(start).Round(time.Millisecond),
+	)
+}
+
+// notify sends the given event to the notifier, if any, and logs the
+// notifier errors.
+func (w *Watcher) notify(ctx context.Context, e notify.Event) {
+	if w.opts.Notifier == nil {
+		return
+	}
+	if err := w.opts.Notifier.Notify(ctx, e); err != nil {
+		w.logger.Warn("notification failed", "host", e.Host, "container", e.Container, "kind", string(e.Kind), "err", err)
+	}
+}
+
+// cronLogger logs the messages of the scheduler to a slog logger.
+type cronLogger struct {
+	logger *slog.Logger
+}
+
+func (l cronLogger) Info(msg string, keysAndValues ...any) {
+	l.logger.Debug(msg, keysAndValues...)
+}
+
+func (l cronLogger) Error(err error, msg string, keysAndValues ...any) {
+	l.logger.Error(msg, append(keysAndValues, "err", err)...)
+}